@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	goldmarkast "github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	goldmarkrenderer "github.com/yuin/goldmark/renderer"
+	goldmarktext "github.com/yuin/goldmark/text"
+	goldmarkutil "github.com/yuin/goldmark/util"
+)
+
+// admonitionTitles are the supported admonition types with their default title.
+var admonitionTitles = map[string]string{
+	"note":      "Note",
+	"tip":       "Tip",
+	"important": "Important",
+	"warning":   "Warning",
+	"caution":   "Caution",
+}
+
+// admonitionNode is a callout box, like a note or a warning.
+type admonitionNode struct {
+	goldmarkast.BaseBlock
+
+	AdmonitionType string
+	Title          string
+}
+
+var kindAdmonition = goldmarkast.NewNodeKind("Admonition")
+
+func (n *admonitionNode) Kind() goldmarkast.NodeKind { return kindAdmonition }
+
+func (n *admonitionNode) Dump(source []byte, level int) {
+	goldmarkast.DumpHelper(n, source, level, map[string]string{
+		"AdmonitionType": n.AdmonitionType,
+		"Title":          n.Title,
+	}, nil)
+}
+
+var admonitionMarkerRegexp = regexp.MustCompile(`^\[!([A-Za-z]+)\][ \t]*(.*)$`)
+
+// admonitionTransformer is a goldmarkast.ASTTransformer that replaces blockquotes starting with a `[!TYPE]` marker with an admonition.
+//
+// The syntax is the same as GitHub alerts, with an optional title after the marker:
+//
+//	> [!WARNING] Back up your data
+//	> This will delete everything.
+//
+// An unknown type is an error.
+type admonitionTransformer struct{}
+
+func (t admonitionTransformer) Transform(node *goldmarkast.Document, reader goldmarktext.Reader, pc goldmarkparser.Context) {
+	source := reader.Source()
+
+	var blockquotes []*goldmarkast.Blockquote
+
+	goldmarkast.Walk(node, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+		if blockquote, ok := n.(*goldmarkast.Blockquote); ok && entering {
+			blockquotes = append(blockquotes, blockquote)
+		}
+		return goldmarkast.WalkContinue, nil
+	})
+
+	for _, blockquote := range blockquotes {
+		paragraph, ok := blockquote.FirstChild().(*goldmarkast.Paragraph)
+		if !ok || paragraph.Lines().Len() == 0 {
+			continue
+		}
+
+		firstLine := paragraph.Lines().At(0)
+
+		matches := admonitionMarkerRegexp.FindStringSubmatch(strings.TrimSpace(string(firstLine.Value(source))))
+		if matches == nil {
+			continue
+		}
+
+		typ := strings.ToLower(matches[1])
+		title, ok := admonitionTitles[typ]
+		if !ok {
+			addTransformError(pc, fmt.Errorf("invalid admonition type %q, should be one of \"note\", \"tip\", \"important\", \"warning\" or \"caution\"", matches[1]))
+			continue
+		}
+		if matches[2] != "" {
+			title = matches[2]
+		}
+
+		// Remove the marker line from the paragraph, and the paragraph itself if nothing is left
+		for child := paragraph.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			if start, ok := inlineStart(child); !ok || start >= firstLine.Stop {
+				break
+			}
+			paragraph.RemoveChild(paragraph, child)
+			child = next
+		}
+		if paragraph.ChildCount() == 0 {
+			blockquote.RemoveChild(blockquote, paragraph)
+		}
+
+		admonition := &admonitionNode{
+			AdmonitionType: typ,
+			Title:          title,
+		}
+		for child := blockquote.FirstChild(); child != nil; {
+			next := child.NextSibling()
+			admonition.AppendChild(admonition, child)
+			child = next
+		}
+
+		blockquote.Parent().ReplaceChild(blockquote.Parent(), blockquote, admonition)
+	}
+}
+
+var _ goldmarkparser.ASTTransformer = admonitionTransformer{}
+
+// inlineStart returns the position in the source of the first text of an inline node.
+func inlineStart(n goldmarkast.Node) (int, bool) {
+	for ; n != nil; n = n.FirstChild() {
+		if text, ok := n.(*goldmarkast.Text); ok {
+			return text.Segment.Start, true
+		}
+	}
+	return 0, false
+}
+
+// admonitionRenderer is a goldmarkrenderer.NodeRenderer rendering an [admonitionNode] as an `<aside>` element.
+type admonitionRenderer struct{}
+
+func (r admonitionRenderer) RegisterFuncs(reg goldmarkrenderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindAdmonition, r.renderAdmonition)
+}
+
+func (r admonitionRenderer) renderAdmonition(w goldmarkutil.BufWriter, source []byte, node goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+	n := node.(*admonitionNode)
+
+	if entering {
+		fmt.Fprintf(w, `<aside class="admonition admonition-%s" role="note">`, n.AdmonitionType)
+		_, _ = w.WriteString("\n")
+		_, _ = w.WriteString(`<p class="admonition-title">`)
+		_, _ = w.Write(goldmarkutil.EscapeHTML([]byte(n.Title)))
+		_, _ = w.WriteString("</p>\n")
+	} else {
+		_, _ = w.WriteString("</aside>\n")
+	}
+
+	return goldmarkast.WalkContinue, nil
+}
+
+var _ goldmarkrenderer.NodeRenderer = admonitionRenderer{}
+
+// admonitionExtension adds admonitions, see [admonitionTransformer].
+type admonitionExtension struct{}
+
+func (e admonitionExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		goldmarkparser.WithASTTransformers(
+			goldmarkutil.Prioritized(admonitionTransformer{}, 200),
+		),
+	)
+	m.Renderer().AddOptions(
+		goldmarkrenderer.WithNodeRenderers(
+			goldmarkutil.Prioritized(admonitionRenderer{}, 500),
+		),
+	)
+}
+
+var _ goldmark.Extender = admonitionExtension{}
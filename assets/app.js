@@ -80,7 +80,40 @@ function initHamburgerMenu() {
   });
 }
 
+function initLightbox() {
+  const links = document.querySelectorAll("figure > a.figure-link");
+
+  if (links.length === 0) return;
+
+  const lightbox = document.createElement("dialog");
+  lightbox.className = "lightbox";
+  document.body.appendChild(lightbox);
+
+  // Close on any click, Escape is handled by the dialog itself
+  lightbox.addEventListener("click", () => {
+    lightbox.close();
+  });
+
+  links.forEach((link) => {
+    link.addEventListener("click", (event) => {
+      event.preventDefault();
+
+      const image = document.createElement("img");
+      image.src = link.href;
+      image.alt = link.querySelector("img")?.alt || "";
+
+      const caption = document.createElement("p");
+      caption.textContent =
+        link.parentElement.querySelector("figcaption")?.textContent || "";
+
+      lightbox.replaceChildren(image, caption);
+      lightbox.showModal();
+    });
+  });
+}
+
 window.addEventListener("DOMContentLoaded", () => {
   initTheme();
   initHamburgerMenu();
+  initLightbox();
 });
@@ -0,0 +1,143 @@
+// Client-side search using the index built by the generator.
+//
+// The index maps stemmed terms to documents. Since there is no stemmer here,
+// a query word matches a term if one is a prefix of the other, which covers
+// stemmed words ("connection" -> "connect") and words being typed ("conn").
+// The words whose term can't be found by prefix ("using" -> "use") are listed
+// in the index, as are the stop words which are not indexed at all.
+
+const SEARCH_INDEX_VERSION = 2;
+
+// Must match tokenizeSearchText in search.go
+function tokenize(text) {
+  return text
+    .toLowerCase()
+    .split(/[^\p{L}\p{N}]+/u)
+    .filter((word) => [...word].length >= 2);
+}
+
+// Must match searchTermMatches in search.go
+function termMatches(term, word) {
+  return term.startsWith(word) || ([...term].length >= 3 && word.startsWith(term));
+}
+
+function matchingTerms(index, word) {
+  const stem = Object.hasOwn(index.stems, word) ? index.stems[word] : null;
+  return Object.keys(index.terms).filter(
+    (term) => term === stem || termMatches(term, word),
+  );
+}
+
+function search(index, query) {
+  const stopWords = new Set(index.stop_words);
+  const words = tokenize(query).filter((word) => !stopWords.has(word));
+  if (words.length === 0) return [];
+
+  // Every word must match, scores are summed
+  let scores = null;
+  for (const word of words) {
+    const wordScores = new Map();
+    for (const term of matchingTerms(index, word)) {
+      for (const [doc, score] of index.terms[term]) {
+        wordScores.set(doc, Math.max(wordScores.get(doc) || 0, score));
+      }
+    }
+
+    if (scores === null) {
+      scores = wordScores;
+    } else {
+      for (const [doc, score] of scores) {
+        if (wordScores.has(doc)) {
+          scores.set(doc, score + wordScores.get(doc));
+        } else {
+          scores.delete(doc);
+        }
+      }
+    }
+  }
+
+  return [...scores]
+    .sort((a, b) => b[1] - a[1])
+    .map(([doc]) => index.documents[doc]);
+}
+
+function renderResults(list, documents) {
+  list.replaceChildren(
+    ...documents.map((document_) => {
+      const item = document.createElement("li");
+
+      const link = document.createElement("a");
+      link.href = document_.url;
+      link.textContent = document_.title;
+      item.append(link);
+
+      if (document_.date) {
+        const date = document.createElement("span");
+        date.textContent = document_.date;
+        item.append(date);
+      }
+
+      return item;
+    }),
+  );
+}
+
+async function initSearch() {
+  const root = document.getElementById("search");
+  if (!root) return;
+
+  const form = document.getElementById("search-form");
+  const input = document.getElementById("search-input");
+  const status = document.getElementById("search-status");
+  const results = document.getElementById("search-results");
+
+  let index;
+  try {
+    const response = await fetch(root.dataset.indexUrl);
+    index = await response.json();
+  } catch (err) {
+    status.textContent = "Unable to load the search index.";
+    return;
+  }
+  if (index.version !== SEARCH_INDEX_VERSION) {
+    status.textContent = "Unsupported search index version.";
+    return;
+  }
+
+  const update = () => {
+    const query = input.value.trim();
+
+    const url = new URL(window.location);
+    if (query) {
+      url.searchParams.set("q", query);
+    } else {
+      url.searchParams.delete("q");
+    }
+    window.history.replaceState(null, "", url);
+
+    if (!query) {
+      status.textContent = "";
+      results.replaceChildren();
+      return;
+    }
+
+    const documents = search(index, query);
+    status.textContent =
+      documents.length === 1 ? "1 result" : `${documents.length} results`;
+    renderResults(results, documents);
+  };
+
+  form.addEventListener("submit", (event) => {
+    event.preventDefault();
+    update();
+  });
+  input.addEventListener("input", update);
+
+  input.value = new URLSearchParams(window.location.search).get("q") || "";
+  input.focus();
+  update();
+}
+
+window.addEventListener("DOMContentLoaded", () => {
+  initSearch();
+});
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"cmp"
 	"context"
 
 	"fmt"
+	"html"
 	"io"
 	"io/fs"
 	"log/slog"
 	"maps"
 	"os"
+	"path"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -21,9 +24,7 @@ import (
 	goldmarkast "github.com/yuin/goldmark/ast"
 	goldmarkparser "github.com/yuin/goldmark/parser"
 	goldmarkrenderer "github.com/yuin/goldmark/renderer"
-	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
 	goldmarktext "github.com/yuin/goldmark/text"
-	goldmarkutil "github.com/yuin/goldmark/util"
 	goldmarktoc "go.abhg.dev/goldmark/toc"
 	"go.uber.org/multierr"
 
@@ -35,6 +36,16 @@ type generateCommandConfig struct {
 	assetsDir string
 	buildDir  string
 
+	redirectsFile string
+	resumeFile    string
+	permalinks    map[string]string
+
+	gitHistory bool
+	historyURL string
+	baseURL    string
+
+	imageCacheDir string
+
 	noAssetsVersioning bool
 
 	logger *slog.Logger
@@ -56,6 +67,13 @@ func newGenerateCmd(logger *slog.Logger) *cobra.Command {
 	cmd.Flags().StringVar(&cfg.pagesDir, "pages-directory", "./pages", "The directory where the markdown pages are stored")
 	cmd.Flags().StringVar(&cfg.assetsDir, "assets-directory", "assets", "The directory where the asset files are stored")
 	cmd.Flags().StringVar(&cfg.buildDir, "build-directory", "build", "The directory where the generated files will be stored")
+	cmd.Flags().StringVar(&cfg.redirectsFile, "redirects-file", "redirects.yaml", "The YAML file listing additional redirects")
+	cmd.Flags().StringVar(&cfg.resumeFile, "resume-file", "resume.yaml", "The YAML file declaring the resume variants")
+	cmd.Flags().StringToStringVar(&cfg.permalinks, "permalink", nil, "The permalink pattern of a format, for example `blog_entry=/blog/:year/:slug`")
+	cmd.Flags().BoolVar(&cfg.gitHistory, "git-history", false, "Use the git history to fill the creation and update dates missing from the pages")
+	cmd.Flags().StringVar(&cfg.historyURL, "history-url", "https://github.com/vrischmann/public-website/commits/main/{path}", "The URL of the history of a page, {path} is replaced by the path of the page in the repository. Requires --git-history")
+	cmd.Flags().StringVar(&cfg.baseURL, "base-url", "https://rischmann.fr", "The URL of the website, used for the absolute URLs of the feeds")
+	cmd.Flags().StringVar(&cfg.imageCacheDir, "image-cache-directory", ".cache/images", "The directory where the processed images are cached")
 	cmd.Flags().BoolVar(&cfg.noAssetsVersioning, "no-assets-versioning", false, "Disable assets versioning")
 
 	return cmd
@@ -89,7 +107,20 @@ func (c *generateCommandConfig) copyVersionedFiles(_ context.Context, generation
 		".avif": {},
 	}
 
-	doCopy := func(dir string, stripPrefix string) error {
+	// PNG and JPEG images in pages are processed by the image pipeline before being copied
+	pagesVersionedExtensions := maps.Clone(versionedExtensions)
+	pagesVersionedExtensions[".png"] = struct{}{}
+	pagesVersionedExtensions[".jpg"] = struct{}{}
+	pagesVersionedExtensions[".jpeg"] = struct{}{}
+	// PDF files in pages are the slides of talks
+	pagesVersionedExtensions[".pdf"] = struct{}{}
+
+	images := &imagePipeline{
+		logger:   c.logger,
+		cacheDir: c.imageCacheDir,
+	}
+
+	doCopy := func(dir string, stripPrefix string, versionedExtensions map[string]struct{}, images *imagePipeline) error {
 		return filepath.WalkDir(dir, func(inputPath string, d fs.DirEntry, err error) error {
 			if err != nil {
 				return err
@@ -112,6 +143,10 @@ func (c *generateCommandConfig) copyVersionedFiles(_ context.Context, generation
 				dir := strings.TrimPrefix(filepath.Dir(inputPath), stripPrefix)
 				outputPath = filepath.Join(dir, name)
 
+				if images != nil && images.handles(ext) {
+					return images.process(inputPath, c.buildDir, dir, generationDate)
+				}
+
 			} else {
 				// Not a file we want versioned, ignore
 				return nil
@@ -154,8 +189,8 @@ func (c *generateCommandConfig) copyVersionedFiles(_ context.Context, generation
 	}
 
 	return multierr.Combine(
-		doCopy(c.assetsDir, ""),
-		doCopy(c.pagesDir, pagesStripPrefix),
+		doCopy(c.assetsDir, "", versionedExtensions, nil),
+		doCopy(c.pagesDir, pagesStripPrefix, pagesVersionedExtensions, images),
 	)
 }
 
@@ -192,6 +227,21 @@ func (t *imageVersioningTransformer) Transform(node *goldmarkast.Document, reade
 		newFilename, _ := renameWithVersion(string(img.Destination), t.generationDate)
 		img.Destination = []byte(newFilename)
 
+		if fallback, ok := img.AttributeString(imageFallbackAttribute); ok {
+			newFallback, _ := renameWithVersion(string(fallback.([]byte)), t.generationDate)
+			img.SetAttributeString(imageFallbackAttribute, []byte(newFallback))
+		}
+
+		if srcset, ok := img.AttributeString("srcset"); ok {
+			candidates := strings.Split(string(srcset.([]byte)), ", ")
+			for i, candidate := range candidates {
+				url, descriptor, _ := strings.Cut(candidate, " ")
+				newURL, _ := renameWithVersion(url, t.generationDate)
+				candidates[i] = newURL + " " + descriptor
+			}
+			img.SetAttributeString("srcset", []byte(strings.Join(candidates, ", ")))
+		}
+
 		seen[img] = struct{}{}
 
 		return goldmarkast.WalkContinue, nil
@@ -200,30 +250,96 @@ func (t *imageVersioningTransformer) Transform(node *goldmarkast.Document, reade
 
 var _ goldmarkparser.ASTTransformer = (*imageVersioningTransformer)(nil)
 
-func (c *generateCommandConfig) generatePages(_ context.Context, generationDate time.Time) error {
+// sourcePathContextKey is used to store the source path of the page being parsed in the goldmark parser context.
+var sourcePathContextKey = goldmarkparser.NewContextKey()
+
+// imagePathTransformer is a goldmarkast.ASTTransformer that makes relative image destinations absolute, based on the source path of the page.
+//
+// This is needed because the output path of a page depends on its permalink and may not be in the same directory as its source.
+type imagePathTransformer struct{}
+
+func (t imagePathTransformer) Transform(node *goldmarkast.Document, reader goldmarktext.Reader, pc goldmarkparser.Context) {
+	sourcePath, ok := pc.Get(sourcePathContextKey).(string)
+	if !ok {
+		return
+	}
+	sourceDir := path.Dir(sourcePath)
+
+	goldmarkast.Walk(node, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+		img, ok := n.(*goldmarkast.Image)
+		if !ok || !entering {
+			return goldmarkast.WalkContinue, nil
+		}
+
+		destination := string(img.Destination)
+		if strings.HasPrefix(destination, "/") || strings.Contains(destination, "://") {
+			return goldmarkast.WalkContinue, nil
+		}
+
+		img.Destination = []byte(path.Join("/", sourceDir, destination))
+
+		return goldmarkast.WalkContinue, nil
+	})
+}
+
+var _ goldmarkparser.ASTTransformer = imagePathTransformer{}
+
+func (c *generateCommandConfig) generatePages(ctx context.Context, generationDate time.Time) error {
+	var history *gitHistory
+	if c.gitHistory {
+		c.logger.Info("reading git history")
+
+		var err error
+		history, err = readGitHistory(ctx, c.pagesDir)
+		if err != nil {
+			return fmt.Errorf("unable to read git history, err: %w", err)
+		}
+	}
+
+	resumeConfig, err := readResumeFile(c.resumeFile)
+	if err != nil {
+		return err
+	}
+
+	generatedPaths := allGeneratedPagePaths(resumeConfig)
+
 	c.logger.Info("collecting pages")
 
-	markdown := goldmark.New(
-		goldmark.WithParserOptions(
-			goldmarkparser.WithAutoHeadingID(),
-			goldmarkparser.WithASTTransformers(
-				goldmarkutil.Prioritized(newImageVersioningTransformer(generationDate), 100),
-			),
-		),
-		goldmark.WithRendererOptions(
-			goldmarkhtml.WithUnsafe(),
-		),
-		goldmark.WithExtensions(
-			goldmarkmeta.Meta,
-		),
-	)
+	// Pages are parsed with the markdown extensions enabled in their front matter.
+	// All extensions are enabled by default so the default instance can render every page.
+	markdown := c.newMarkdown(generationDate, defaultMarkdownOptions)
+
+	parsers := newMarkdownParsers(func(options markdownOptions) goldmark.Markdown {
+		return c.newMarkdown(generationDate, options)
+	})
 
 	// Collect pages
-	allPages, err := collectPages(c.pagesDir, markdown.Parser())
+	permalinks := maps.Clone(defaultPermalinks)
+	maps.Copy(permalinks, c.permalinks)
+
+	allPages, err := collectPages(c.pagesDir, parsers, permalinks, generatedPaths, history)
 	if err != nil {
 		return fmt.Errorf("unable to collect pages, err: %w", err)
 	}
 
+	// Resolve the links between pages
+	err = transformPages(allPages,
+		newPageLinkTransformer(allPages),
+		newWikiLinkTransformer(allPages),
+	)
+	if err != nil {
+		return err
+	}
+
+	backlinks := collectBacklinks(allPages)
+	blogNavigation := collectBlogNavigation(allPages)
+	for i := range allPages {
+		allPages[i].backlinks = backlinks[allPages[i].url()]
+		allPages[i].blogNavigation = blogNavigation[allPages[i].url()]
+		allPages[i].summary = summarizePage(allPages[i])
+		allPages[i].historyURL = history.historyURL(c.historyURL, allPages[i].sourcePath)
+	}
+
 	// Process pages
 	for _, page := range allPages {
 		if err := page.generate(c.logger, generationDate, markdown.Renderer(), c.buildDir); err != nil {
@@ -236,11 +352,44 @@ func (c *generateCommandConfig) generatePages(_ context.Context, generationDate
 		return fmt.Errorf("unable to generate blog index, err: %w", err)
 	}
 
+	// Generate the code index page
+	if err := generateCodeIndex(c.logger, generationDate, c.buildDir, allPages); err != nil {
+		return fmt.Errorf("unable to generate code index, err: %w", err)
+	}
+
+	// Generate the talks index page
+	if err := generateTalksIndex(c.logger, generationDate, c.pagesDir, c.buildDir, allPages); err != nil {
+		return fmt.Errorf("unable to generate talks index, err: %w", err)
+	}
+
+	// Generate the notes stream and feed
+	if err := generateNotes(c.logger, generationDate, markdown.Renderer(), c.buildDir, strings.TrimSuffix(c.baseURL, "/"), allPages); err != nil {
+		return fmt.Errorf("unable to generate notes, err: %w", err)
+	}
+
 	// Generate the resume page
-	if err := generateResume(c.logger, generationDate, markdown.Renderer(), c.buildDir, allPages); err != nil {
+	if err := generateResume(c.logger, generationDate, markdown.Renderer(), c.buildDir, allPages, resumeConfig); err != nil {
 		return fmt.Errorf("unable to generate resume, err: %w", err)
 	}
 
+	// Generate the search index and page
+	if err := generateSearch(c.logger, generationDate, c.buildDir, allPages); err != nil {
+		return fmt.Errorf("unable to generate search, err: %w", err)
+	}
+
+	// Generate the redirects
+	globalRedirects, err := readRedirectsFile(c.redirectsFile)
+	if err != nil {
+		return err
+	}
+	redirects, err := collectRedirects(allPages, generatedPaths, globalRedirects)
+	if err != nil {
+		return fmt.Errorf("unable to collect redirects, err: %w", err)
+	}
+	if err := generateRedirects(c.logger, c.buildDir, redirects); err != nil {
+		return fmt.Errorf("unable to generate redirects, err: %w", err)
+	}
+
 	return nil
 }
 
@@ -278,22 +427,47 @@ func createOutputFile(buildRootDir string, path string) (*os.File, error) {
 	return f, nil
 }
 
+func writeOutputFile(buildRootDir string, path string, content string) error {
+	f, err := createOutputFile(buildRootDir, path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		return fmt.Errorf("unable to write file %q, err: %w", f.Name(), err)
+	}
+
+	return nil
+}
+
 const (
 	formatStandard   = "standard"
 	formatBlogEntry  = "blog_entry"
 	formatResumePart = "resume_part"
+	formatProject    = "project"
+	formatTalk       = "talk"
+	formatNote       = "note"
 )
 
 type pageMetadata struct {
 	Title       string
 	Description string
 	Date        time.Time
+	Updated     time.Time
 	Format      string
+	Aliases     []string
+	Slug        string
+	Permalink   string
+	Series      string
+	SeriesOrder int
+	Markdown    markdownOptions
 	Extra       map[string]any
 }
 
 func parsePageMetadata(metadata map[string]any) (pageMetadata, error) {
 	var res pageMetadata
+	res.Markdown = defaultMarkdownOptions
 	res.Extra = make(map[string]any)
 
 	maps.Copy(res.Extra, metadata)
@@ -314,15 +488,26 @@ func parsePageMetadata(metadata map[string]any) (pageMetadata, error) {
 		}
 	}
 
-	if tmp, ok := res.Extra["date"]; ok {
+	for _, field := range []struct {
+		key string
+		ptr *time.Time
+	}{
+		{"date", &res.Date},
+		{"updated", &res.Updated},
+	} {
+		tmp, ok := res.Extra[field.key]
+		if !ok {
+			continue
+		}
+
 		if dateStr, ok := tmp.(string); ok {
 			date, err := time.Parse("2006 January 02", dateStr)
 			if err != nil {
-				return pageMetadata{}, fmt.Errorf("invalid `date` value %q, should be a date in the `2006 Jan 02` format", dateStr)
+				return pageMetadata{}, fmt.Errorf("invalid `%s` value %q, should be a date in the `2006 Jan 02` format", field.key, dateStr)
 			}
-			res.Date = date
+			*field.ptr = date
 		} else {
-			return pageMetadata{}, fmt.Errorf("invalid `date` value %v, should be a string", tmp)
+			return pageMetadata{}, fmt.Errorf("invalid `%s` value %v, should be a string", field.key, tmp)
 		}
 	}
 
@@ -334,16 +519,86 @@ func parsePageMetadata(metadata map[string]any) (pageMetadata, error) {
 		}
 	}
 
+	if tmp, ok := res.Extra["slug"]; ok {
+		if slug, ok := tmp.(string); ok && slug != "" && !strings.Contains(slug, "/") {
+			res.Slug = slug
+		} else {
+			return pageMetadata{}, fmt.Errorf("invalid `slug` value %v, should be a non-empty string without slashes", tmp)
+		}
+	}
+
+	if tmp, ok := res.Extra["permalink"]; ok {
+		if permalink, ok := tmp.(string); ok {
+			res.Permalink = permalink
+		} else {
+			return pageMetadata{}, fmt.Errorf("invalid `permalink` value %v, should be a string", tmp)
+		}
+	}
+
+	if tmp, ok := res.Extra["series"]; ok {
+		if series, ok := tmp.(string); ok {
+			res.Series = series
+		} else {
+			return pageMetadata{}, fmt.Errorf("invalid `series` value %v, should be a string", tmp)
+		}
+	}
+
+	if tmp, ok := res.Extra["series_order"]; ok {
+		if seriesOrder, ok := tmp.(int); ok {
+			res.SeriesOrder = seriesOrder
+		} else {
+			return pageMetadata{}, fmt.Errorf("invalid `series_order` value %v, should be an integer", tmp)
+		}
+	}
+
+	if tmp, ok := res.Extra["markdown"]; ok {
+		options, err := parseMarkdownOptions(tmp)
+		if err != nil {
+			return pageMetadata{}, err
+		}
+		res.Markdown = options
+	}
+
+	if tmp, ok := res.Extra["aliases"]; ok {
+		list, ok := tmp.([]any)
+		if !ok {
+			return pageMetadata{}, fmt.Errorf("invalid `aliases` value %v, should be a list of strings", tmp)
+		}
+
+		for _, item := range list {
+			alias, ok := item.(string)
+			if !ok {
+				return pageMetadata{}, fmt.Errorf("invalid `aliases` item %v, should be a string", item)
+			}
+			res.Aliases = append(res.Aliases, alias)
+		}
+	}
+
 	return res, nil
 }
 
 // page represents a markdown page
 type page struct {
-	path       string // found while walking the pages root directory
+	sourcePath string // found while walking the pages root directory, relative to it
+	path       string // output path computed from the permalink pattern, without leading slash or extension
 	sourceData []byte // source bytes
 
 	markdownDocument goldmarkast.Node // parsed from the source bytes
 	metadata         pageMetadata     // found in the YAML header of the markdown page
+
+	backlinks      []templates.Link         // pages linking to this page, computed once all pages are collected
+	blogNavigation templates.BlogNavigation // only for blog entries, computed once all pages are collected
+	summary        pageSummary              // computed once all pages are collected
+	historyURL     string                   // link to the history of the page source, empty if unknown
+}
+
+// unescapeTOCTitles decodes the HTML entities produced by the typographer in the titles of the ToC,
+// otherwise they would be escaped again when rendering the ToC.
+func unescapeTOCTitles(items goldmarktoc.Items) {
+	for _, item := range items {
+		item.Title = []byte(html.UnescapeString(string(item.Title)))
+		unescapeTOCTitles(item.Items)
+	}
 }
 
 func (p page) generate(logger *slog.Logger, generationDate time.Time, renderer goldmarkrenderer.Renderer, buildRootDir string) error {
@@ -359,6 +614,9 @@ func (p page) generate(logger *slog.Logger, generationDate time.Time, renderer g
 			assets.add("prism.js")
 		}
 	}
+	if hasMath(p.markdownDocument) {
+		assets.add("math.css")
+	}
 
 	//
 
@@ -377,7 +635,37 @@ func (p page) generate(logger *slog.Logger, generationDate time.Time, renderer g
 				Description: p.metadata.Description,
 			},
 			assets.underlying,
-			content,
+			templ.Join(
+				content,
+				templates.PageHistory(p.metadata.Updated, p.historyURL),
+				templates.Backlinks(p.backlinks),
+			),
+		)
+
+	case formatProject:
+		project, err := parseProject(p.metadata)
+		if err != nil {
+			return fmt.Errorf("invalid project %s, err: %w", p.sourcePath, err)
+		}
+		project.page = p
+
+		content := markdownHTMLComponent{
+			renderer: renderer,
+			source:   p.sourceData,
+			node:     p.markdownDocument,
+		}
+
+		page = templates.Page(
+			templates.HeaderParams{
+				Title:       p.metadata.Title,
+				Description: cmp.Or(p.metadata.Description, project.Tagline),
+			},
+			assets.underlying,
+			templ.Join(
+				templates.ProjectContent(project.templateProject(), content),
+				templates.PageHistory(p.metadata.Updated, p.historyURL),
+				templates.Backlinks(p.backlinks),
+			),
 		)
 
 	case formatBlogEntry:
@@ -386,6 +674,7 @@ func (p page) generate(logger *slog.Logger, generationDate time.Time, renderer g
 		if err != nil {
 			return fmt.Errorf("unable to generate table of contents for page %s, err: %w", p.path, err)
 		}
+		unescapeTOCTitles(toc.Items)
 
 		tableOfContents := markdownHTMLComponent{
 			renderer: renderer,
@@ -399,17 +688,82 @@ func (p page) generate(logger *slog.Logger, generationDate time.Time, renderer g
 			node:     p.markdownDocument,
 		}
 
-		blogContent := templates.BlogContent(p.metadata.Title, p.metadata.Date, tableOfContents, content)
+		blogContent := templates.BlogContent(
+			templates.BlogContentParams{
+				Title:       p.metadata.Title,
+				Date:        p.metadata.Date,
+				Updated:     p.metadata.Updated,
+				HistoryURL:  p.historyURL,
+				WordCount:   p.summary.WordCount,
+				ReadingTime: p.summary.ReadingTime,
+				Backlinks:   p.backlinks,
+				Navigation:  p.blogNavigation,
+			},
+			tableOfContents,
+			content,
+		)
 
 		page = templates.Page(
 			templates.HeaderParams{
 				Title:       p.metadata.Title,
-				Description: p.metadata.Description,
+				Description: p.summary.Excerpt,
 			},
 			assets.underlying,
 			blogContent,
 		)
 
+	case formatNote:
+		note := templateNote(renderer, p)
+
+		var updated time.Time
+		if templates.UpdatedAfter(p.metadata.Updated, p.metadata.Date) {
+			updated = p.metadata.Updated
+		}
+
+		page = templates.Page(
+			templates.HeaderParams{
+				Title:       p.metadata.Title,
+				Description: p.summary.Excerpt,
+				Feed:        "/" + notesFeedPath,
+			},
+			assets.underlying,
+			templ.Join(
+				templates.NoteContent(note),
+				templates.PageHistory(updated, p.historyURL),
+				templates.Backlinks(p.backlinks),
+			),
+		)
+
+	case formatTalk:
+		talk, err := parseTalk(p)
+		if err != nil {
+			return fmt.Errorf("invalid talk %s, err: %w", p.sourcePath, err)
+		}
+
+		content := markdownHTMLComponent{
+			renderer: renderer,
+			source:   p.sourceData,
+			node:     p.markdownDocument,
+		}
+
+		var updated time.Time
+		if templates.UpdatedAfter(p.metadata.Updated, p.metadata.Date) {
+			updated = p.metadata.Updated
+		}
+
+		page = templates.Page(
+			templates.HeaderParams{
+				Title:       p.metadata.Title,
+				Description: p.metadata.Description,
+			},
+			assets.underlying,
+			templ.Join(
+				templates.TalkContent(talk.templateTalk(generationDate), content),
+				templates.PageHistory(updated, p.historyURL),
+				templates.Backlinks(p.backlinks),
+			),
+		)
+
 	default:
 		logger.Debug("skipping page, unknown format", slog.String("path", p.path))
 		return nil
@@ -435,6 +789,72 @@ func (p page) generate(logger *slog.Logger, generationDate time.Time, renderer g
 	return nil
 }
 
+// stringField is a string front matter value, used to parse the front matter specific to a format.
+type stringField struct {
+	key      string
+	ptr      *string
+	required bool
+}
+
+func parseStringFields(metadata pageMetadata, fields []stringField) error {
+	for _, field := range fields {
+		tmp, ok := metadata.Extra[field.key]
+		if !ok {
+			if field.required {
+				return fmt.Errorf("missing `%s` value", field.key)
+			}
+			continue
+		}
+
+		value, ok := tmp.(string)
+		if !ok || value == "" {
+			return fmt.Errorf("invalid `%s` value %v, should be a non empty string", field.key, tmp)
+		}
+		*field.ptr = value
+	}
+
+	return nil
+}
+
+// parseStringList parses an optional front matter value containing a list of strings.
+func parseStringList(metadata pageMetadata, key string) ([]string, error) {
+	tmp, ok := metadata.Extra[key]
+	if !ok {
+		return nil, nil
+	}
+
+	values, ok := tmp.([]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid `%s` value %v, should be a list of strings", key, tmp)
+	}
+
+	res := make([]string, 0, len(values))
+	for _, v := range values {
+		value, ok := v.(string)
+		if !ok || value == "" {
+			return nil, fmt.Errorf("invalid `%s` element %v, should be a non empty string", key, v)
+		}
+		res = append(res, value)
+	}
+
+	return res, nil
+}
+
+// isRendered returns true if the page is rendered to its own HTML file.
+func (p page) isRendered() bool {
+	switch p.metadata.Format {
+	case formatStandard, formatBlogEntry, formatProject, formatTalk, formatNote:
+		return true
+	default:
+		return false
+	}
+}
+
+// url returns the absolute URL of the page on the website.
+func (p page) url() string {
+	return "/" + p.path
+}
+
 type pages []page
 
 func (p pages) getAll(format string) []page {
@@ -447,7 +867,13 @@ func (p pages) getAll(format string) []page {
 	return res
 }
 
-func collectPages(rootDir string, parser goldmarkparser.Parser) (res []page, err error) {
+// collectPages parses all markdown pages in rootDir.
+//
+// The output path of each page is computed from its `permalink` front matter value if present,
+// otherwise from the pattern configured in permalinks for its format.
+//
+// If history is not nil, it is used to fill the dates missing from the front matter.
+func collectPages(rootDir string, parsers *markdownParsers, permalinks map[string]string, generatedPaths map[string]string, history *gitHistory) (res []page, err error) {
 	err = filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -459,52 +885,118 @@ func collectPages(rootDir string, parser goldmarkparser.Parser) (res []page, err
 			return nil
 		}
 
-		var page page
+		// Get the source path relative to the root directory
+		relativePath, err := filepath.Rel(rootDir, path)
+		if err != nil {
+			return fmt.Errorf("unable to get relative path of %s, err: %w", path, err)
+		}
+		sourcePath := filepath.ToSlash(relativePath)
 
 		// Parse and convert the page
-		goldmarkContext := goldmarkparser.NewContext()
-		{
-			data, err := os.ReadFile(path)
-			if err != nil {
-				return fmt.Errorf("unable to read file %q, err: %w", path, err)
-			}
-			document := parser.Parse(goldmarktext.NewReader(data),
-				goldmarkparser.WithContext(goldmarkContext),
-			)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read file %q, err: %w", path, err)
+		}
+
+		parsed, err := parsePage(parsers, sourcePath, data, history)
+		if err != nil {
+			return err
+		}
 
-			page.sourceData = data
-			page.markdownDocument = document
+		// The title of a project defaults to its name, for the page title, the search and the backlinks
+		if parsed.metadata.Format == formatProject && parsed.metadata.Title == "" {
+			parsed.metadata.Title, _ = parsed.metadata.Extra["name"].(string)
 		}
 
-		// Parse the metadata from the markdown page
-		{
-			md, err := parsePageMetadata(goldmarkmeta.Get(goldmarkContext))
+		// A notes file can contain multiple notes
+		filePages := []page{parsed}
+		if parsed.metadata.Format == formatNote {
+			filePages, err = splitNotes(parsers, parsed, history)
 			if err != nil {
 				return err
 			}
-
-			page.metadata = md
 		}
 
-		// Convert the path
-		{
-			relativePath, err := filepath.Rel(rootDir, path)
-			if err != nil {
-				return fmt.Errorf("unable to get relative path of %s, err: %w", path, err)
+		// Compute the output path
+		for _, page := range filePages {
+			pattern := page.metadata.Permalink
+			if pattern == "" {
+				pattern = permalinks[page.metadata.Format]
+			}
+			if pattern == "" {
+				pattern = defaultPermalinkPattern
 			}
 
-			ext := filepath.Ext(relativePath)
-			path := relativePath[:len(relativePath)-len(ext)]
+			path, err := expandPermalink(pattern, page.sourcePath, page.metadata)
+			if err != nil {
+				return fmt.Errorf("unable to compute output path of page %s, err: %w", page.sourcePath, err)
+			}
 
 			page.path = path
-		}
 
-		res = append(res, page)
+			res = append(res, page)
+		}
 
 		return nil
 	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkPagePathCollisions(res, generatedPaths); err != nil {
+		return nil, err
+	}
 
-	return res, err
+	return res, nil
+}
+
+// parsePage parses the markdown source of a page and its front matter.
+//
+// The output path of the page is not computed.
+func parsePage(parsers *markdownParsers, sourcePath string, data []byte, history *gitHistory) (page, error) {
+	var page page
+	page.sourcePath = sourcePath
+	page.sourceData = data
+
+	parse := func(parser goldmarkparser.Parser) (goldmarkparser.Context, error) {
+		goldmarkContext := goldmarkparser.NewContext()
+		goldmarkContext.Set(sourcePathContextKey, page.sourcePath)
+
+		page.markdownDocument = parser.Parse(goldmarktext.NewReader(data),
+			goldmarkparser.WithContext(goldmarkContext),
+		)
+
+		if err, ok := goldmarkContext.Get(transformErrorContextKey).(error); ok && err != nil {
+			return nil, fmt.Errorf("unable to parse page %s, err: %w", page.sourcePath, err)
+		}
+
+		return goldmarkContext, nil
+	}
+
+	goldmarkContext, err := parse(parsers.get(defaultMarkdownOptions))
+	if err != nil {
+		return page, err
+	}
+
+	// Parse the metadata from the markdown page
+	{
+		md, err := parsePageMetadata(goldmarkmeta.Get(goldmarkContext))
+		if err != nil {
+			return page, fmt.Errorf("invalid metadata in page %s, err: %w", page.sourcePath, err)
+		}
+		history.apply(page.sourcePath, &md)
+
+		page.metadata = md
+	}
+
+	// The markdown options are only known once the front matter is parsed, parse the page again if they're not the default ones
+	if page.metadata.Markdown != defaultMarkdownOptions {
+		if _, err := parse(parsers.get(page.metadata.Markdown)); err != nil {
+			return page, err
+		}
+	}
+
+	return page, nil
 }
 
 func generateBlogIndex(logger *slog.Logger, generationDate time.Time, buildRootDir string, pages pages) error {
@@ -520,16 +1012,14 @@ func generateBlogIndex(logger *slog.Logger, generationDate time.Time, buildRootD
 	for _, page := range pages.getAll(formatBlogEntry) {
 		year := page.metadata.Date.Year()
 
-		linkURL := page.path
-		if linkURL[0] != '/' {
-			linkURL = "/" + linkURL
-		}
-
 		items := blogItemsPerYear[year]
 		items = append(items, templates.BlogItem{
-			LinkURL:  linkURL,
-			LinkText: page.metadata.Title,
-			Date:     page.metadata.Date,
+			LinkURL:     page.url(),
+			LinkText:    page.metadata.Title,
+			Date:        page.metadata.Date,
+			Excerpt:     page.summary.Excerpt,
+			WordCount:   page.summary.WordCount,
+			ReadingTime: page.summary.ReadingTime,
 		})
 
 		blogItemsPerYear[year] = items
@@ -581,81 +1071,6 @@ func generateBlogIndex(logger *slog.Logger, generationDate time.Time, buildRootD
 	return nil
 }
 
-func generateResume(logger *slog.Logger, generationDate time.Time, render goldmarkrenderer.Renderer, buildRootDir string, pages pages) error {
-	ctx := context.Background()
-
-	assets := newAssets(generationDate)
-	assets.add("style.css")
-	assets.add("app.js")
-
-	// Build the resume compoentns
-
-	var (
-		skills       templ.Component
-		experience   []templ.Component
-		sideProjects templ.Component
-	)
-
-	resumeParts := pages.getAll(formatResumePart)
-	for _, part := range resumeParts {
-		id, ok := part.metadata.Extra["id"]
-		if !ok {
-			continue
-		}
-
-		switch id {
-		case "skills":
-			skills = markdownHTMLComponent{
-				renderer: render,
-				source:   part.sourceData,
-				node:     part.markdownDocument,
-			}
-
-		case "work_experience":
-			experience = append(experience, markdownHTMLComponent{
-				renderer: render,
-				source:   part.sourceData,
-				node:     part.markdownDocument,
-			})
-
-		case "side_projects":
-			sideProjects = markdownHTMLComponent{
-				renderer: render,
-				source:   part.sourceData,
-				node:     part.markdownDocument,
-			}
-		}
-	}
-
-	resume := templates.Resume(skills, experience, sideProjects)
-	page := templates.ResumePage(
-		templates.HeaderParams{
-			Title:       "Vincent Rischmann - Resume",
-			Description: "",
-		},
-		assets.underlying,
-		resume,
-	)
-
-	// Rendering page
-
-	f, err := createOutputFile(buildRootDir, "resume.html")
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	logger.Info("generating resume",
-		slog.String("output_path", f.Name()),
-	)
-
-	if err := page.Render(ctx, f); err != nil {
-		return fmt.Errorf("unable to render page to file %q, err: %w", f.Name(), err)
-	}
-
-	return nil
-}
-
 type assets struct {
 	generationDate time.Time
 	underlying     templates.Assets
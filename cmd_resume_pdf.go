@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/yuin/goldmark"
+)
+
+type resumePDFCommandConfig struct {
+	pagesDir   string
+	assetsDir  string
+	resumeFile string
+	variant    string
+	output     string
+
+	logger *slog.Logger
+}
+
+func newResumePDFCmd(logger *slog.Logger) *cobra.Command {
+	cfg := &resumePDFCommandConfig{
+		logger: logger,
+	}
+
+	cmd := &cobra.Command{
+		Use:   "resume-pdf",
+		Short: "generate the PDF resume",
+		Long: `Generate the PDF resume from the resume parts.
+
+The output is deterministic: the date of the document is read from the SOURCE_DATE_EPOCH environment variable
+and defaults to the Unix epoch.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cfg.Exec(context.Background(), args)
+		},
+	}
+
+	cmd.Flags().StringVar(&cfg.pagesDir, "pages-directory", "./pages", "The directory where the markdown pages are stored")
+	cmd.Flags().StringVar(&cfg.assetsDir, "assets-directory", "assets", "The directory where the asset files are stored")
+	cmd.Flags().StringVar(&cfg.resumeFile, "resume-file", "resume.yaml", "The YAML file declaring the resume variants")
+	cmd.Flags().StringVar(&cfg.variant, "variant", "", "The resume variant to generate, the default resume if empty")
+	cmd.Flags().StringVar(&cfg.output, "output", "files/resume.pdf", "The path of the generated PDF file")
+
+	return cmd
+}
+
+func (c *resumePDFCommandConfig) Exec(ctx context.Context, args []string) error {
+	date, err := sourceDateEpoch()
+	if err != nil {
+		return err
+	}
+
+	resumeConfig, err := readResumeFile(c.resumeFile)
+	if err != nil {
+		return err
+	}
+
+	variant := resumeConfig.defaultVariant()
+	if c.variant != "" {
+		variant = resumeConfig.variant(c.variant)
+		if variant == nil {
+			return fmt.Errorf("unknown resume variant %q", c.variant)
+		}
+	}
+
+	// Collect the resume parts, parsed like when generating the website
+
+	generate := &generateCommandConfig{
+		pagesDir:  c.pagesDir,
+		assetsDir: c.assetsDir,
+		logger:    c.logger,
+	}
+	parsers := newMarkdownParsers(func(options markdownOptions) goldmark.Markdown {
+		return generate.newMarkdown(time.Time{}, options)
+	})
+
+	pages, err := collectPages(c.pagesDir, parsers, defaultPermalinks, allGeneratedPagePaths(resumeConfig), nil)
+	if err != nil {
+		return fmt.Errorf("unable to collect pages, err: %w", err)
+	}
+
+	data, err := collectResume(pages, variant)
+	if err != nil {
+		return fmt.Errorf("unable to collect resume, err: %w", err)
+	}
+
+	// Render the PDF
+
+	f, err := os.Create(c.output)
+	if err != nil {
+		return fmt.Errorf("unable to create output file %q, err: %w", c.output, err)
+	}
+	defer f.Close()
+
+	c.logger.Info("generating PDF resume",
+		slog.String("output_path", f.Name()),
+	)
+
+	if err := renderResumePDF(f, data, date); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
+// sourceDateEpoch returns the date defined by the SOURCE_DATE_EPOCH environment variable used for reproducible builds,
+// or the Unix epoch if it's not defined.
+func sourceDateEpoch() (time.Time, error) {
+	value := os.Getenv("SOURCE_DATE_EPOCH")
+	if value == "" {
+		return time.Unix(0, 0).UTC(), nil
+	}
+
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH value %q, should be a number of seconds", value)
+	}
+
+	return time.Unix(seconds, 0).UTC(), nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/yuin/goldmark"
+	goldmarkast "github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	goldmarkrenderer "github.com/yuin/goldmark/renderer"
+	goldmarktext "github.com/yuin/goldmark/text"
+	goldmarkutil "github.com/yuin/goldmark/util"
+)
+
+// figureNode is an image with a caption, rendered as a `<figure>` element.
+//
+// Its only child is the image.
+type figureNode struct {
+	goldmarkast.BaseBlock
+
+	Caption []byte
+	// OriginalURL is the URL of the full size image, empty if the figure must not link to it.
+	OriginalURL string
+}
+
+var kindFigure = goldmarkast.NewNodeKind("Figure")
+
+func (n *figureNode) Kind() goldmarkast.NodeKind { return kindFigure }
+
+func (n *figureNode) Dump(source []byte, level int) {
+	goldmarkast.DumpHelper(n, source, level, map[string]string{
+		"Caption":     string(n.Caption),
+		"OriginalURL": n.OriginalURL,
+	}, nil)
+}
+
+// figureTransformer is a goldmarkast.ASTTransformer that replaces a paragraph containing only an image with a title,
+// like `![alt](src "caption")`, with a figure using the title as caption.
+//
+// Local images link to their full size original, unless the image is already inside a link.
+//
+// It must run after [imageVersioningTransformer] because the link to the original uses the final image URL.
+type figureTransformer struct{}
+
+func (t figureTransformer) Transform(node *goldmarkast.Document, reader goldmarktext.Reader, pc goldmarkparser.Context) {
+	var paragraphs []*goldmarkast.Paragraph
+
+	goldmarkast.Walk(node, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+		if !entering {
+			return goldmarkast.WalkContinue, nil
+		}
+		if paragraph, ok := n.(*goldmarkast.Paragraph); ok {
+			paragraphs = append(paragraphs, paragraph)
+			return goldmarkast.WalkSkipChildren, nil
+		}
+		return goldmarkast.WalkContinue, nil
+	})
+
+	for _, paragraph := range paragraphs {
+		if paragraph.ChildCount() != 1 {
+			continue
+		}
+
+		// The image is either the only child of the paragraph or the only child of a link
+		child := paragraph.FirstChild()
+
+		img, ok := child.(*goldmarkast.Image)
+		if link, isLink := child.(*goldmarkast.Link); isLink && link.ChildCount() == 1 {
+			img, ok = link.FirstChild().(*goldmarkast.Image)
+		}
+		if !ok || len(img.Title) == 0 {
+			continue
+		}
+
+		figure := &figureNode{
+			Caption: img.Title,
+		}
+		img.Title = nil
+
+		if child == img && strings.HasPrefix(string(img.Destination), "/") {
+			figure.OriginalURL = string(img.Destination)
+			if fallback, ok := img.AttributeString(imageFallbackAttribute); ok {
+				figure.OriginalURL = string(fallback.([]byte))
+			}
+		}
+
+		figure.AppendChild(figure, child)
+		paragraph.Parent().ReplaceChild(paragraph.Parent(), paragraph, figure)
+	}
+}
+
+var _ goldmarkparser.ASTTransformer = figureTransformer{}
+
+// figureRenderer is a goldmarkrenderer.NodeRenderer rendering a [figureNode].
+//
+// The figure is linked to the full size image with the `figure-link` class, `assets/app.js` opens these links in a lightbox.
+type figureRenderer struct{}
+
+func (r figureRenderer) RegisterFuncs(reg goldmarkrenderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindFigure, r.renderFigure)
+}
+
+func (r figureRenderer) renderFigure(w goldmarkutil.BufWriter, source []byte, node goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+	n := node.(*figureNode)
+
+	if entering {
+		_, _ = w.WriteString("<figure>")
+		if n.OriginalURL != "" {
+			_, _ = w.WriteString(`<a class="figure-link" href="`)
+			_, _ = w.Write(goldmarkutil.EscapeHTML(goldmarkutil.URLEscape([]byte(n.OriginalURL), true)))
+			_, _ = w.WriteString(`">`)
+		}
+		return goldmarkast.WalkContinue, nil
+	}
+
+	if n.OriginalURL != "" {
+		_, _ = w.WriteString("</a>")
+	}
+	_, _ = w.WriteString("<figcaption>")
+	_, _ = w.Write(goldmarkutil.EscapeHTML(n.Caption))
+	_, _ = w.WriteString("</figcaption></figure>\n")
+
+	return goldmarkast.WalkContinue, nil
+}
+
+var _ goldmarkrenderer.NodeRenderer = figureRenderer{}
+
+// figureExtension adds figures with captions, see [figureTransformer].
+type figureExtension struct{}
+
+func (e figureExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		goldmarkparser.WithASTTransformers(
+			goldmarkutil.Prioritized(figureTransformer{}, 200),
+		),
+	)
+	m.Renderer().AddOptions(
+		goldmarkrenderer.WithNodeRenderers(
+			goldmarkutil.Prioritized(figureRenderer{}, 500),
+		),
+	)
+}
+
+var _ goldmark.Extender = figureExtension{}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// gitFileHistory contains the dates of the first and last commits touching a file.
+type gitFileHistory struct {
+	Created time.Time
+	Updated time.Time
+}
+
+// gitHistory is the history of all files in a directory tracked by git.
+type gitHistory struct {
+	// prefix is the path of the directory relative to the repository root, with a trailing slash.
+	prefix string
+	// files maps a path relative to the directory to its history.
+	files map[string]gitFileHistory
+}
+
+// readGitHistory reads the history of every file in dir by running `git log`.
+func readGitHistory(ctx context.Context, dir string) (*gitHistory, error) {
+	res := &gitHistory{
+		files: make(map[string]gitFileHistory),
+	}
+
+	// Get the prefix
+	{
+		output, err := runGit(ctx, dir, "rev-parse", "--show-prefix")
+		if err != nil {
+			return nil, err
+		}
+		res.prefix = strings.TrimSpace(string(output))
+	}
+
+	// Get the commit dates of every file
+	//
+	// Commits are listed from the most recent to the oldest, each commit is a line with
+	// the commit date followed by the status and path of every file touched by the commit.
+	//
+	// Renames are detected so a moved file keeps the history of its old path.
+	{
+		const datePrefix = "date:"
+
+		output, err := runGit(ctx, dir, "-c", "core.quotePath=false", "log", "--format="+datePrefix+"%cI", "--name-status", "-M", "--relative", "--", ".")
+		if err != nil {
+			return nil, err
+		}
+
+		var commitDate time.Time
+
+		// renames maps an old path to the current path of the file.
+		// The current path is empty if the file was deleted, the history of its path before that is not the one of an existing file.
+		renames := make(map[string]string)
+		currentPath := func(path string) string {
+			if current, ok := renames[path]; ok {
+				return current
+			}
+			return path
+		}
+
+		scanner := bufio.NewScanner(bytes.NewReader(output))
+		for scanner.Scan() {
+			line := scanner.Text()
+			if line == "" {
+				continue
+			}
+
+			if dateStr, ok := strings.CutPrefix(line, datePrefix); ok {
+				commitDate, err = time.Parse(time.RFC3339, dateStr)
+				if err != nil {
+					return nil, fmt.Errorf("invalid commit date %q, err: %w", dateStr, err)
+				}
+				continue
+			}
+
+			fields := strings.Split(line, "\t")
+			if len(fields) < 2 {
+				return nil, fmt.Errorf("invalid git log line %q", line)
+			}
+			status, path := fields[0], fields[len(fields)-1]
+
+			current := currentPath(path)
+			switch {
+			case strings.HasPrefix(status, "R") && len(fields) == 3:
+				renames[fields[1]] = current
+			case status == "D":
+				renames[path] = ""
+				continue
+			}
+			if current == "" {
+				continue
+			}
+
+			history, ok := res.files[current]
+			if !ok {
+				history.Updated = commitDate
+			}
+			history.Created = commitDate
+			res.files[current] = history
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("unable to read git log output, err: %w", err)
+		}
+	}
+
+	return res, nil
+}
+
+func runGit(ctx context.Context, dir string, args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to run git %s, err: %w, stderr: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+
+	return output, nil
+}
+
+// historyURL returns the URL of the history of a page, built from pattern by replacing `{path}`
+// with the path of the page source relative to the repository root.
+func (h *gitHistory) historyURL(pattern string, sourcePath string) string {
+	if h == nil || pattern == "" {
+		return ""
+	}
+	if _, ok := h.files[sourcePath]; !ok {
+		return ""
+	}
+	return strings.ReplaceAll(pattern, "{path}", h.prefix+sourcePath)
+}
+
+// apply fills the dates of the page metadata not set in the front matter.
+func (h *gitHistory) apply(sourcePath string, metadata *pageMetadata) {
+	if h == nil {
+		return
+	}
+
+	history, ok := h.files[sourcePath]
+	if !ok {
+		return
+	}
+
+	if metadata.Date.IsZero() {
+		metadata.Date = history.Created
+	}
+	if metadata.Updated.IsZero() {
+		metadata.Updated = history.Updated
+	}
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// gitFixture is a git repository in a temporary directory, with commits made at fixed dates.
+type gitFixture struct {
+	t   *testing.T
+	dir string
+}
+
+func newGitFixture(t *testing.T) *gitFixture {
+	t.Helper()
+
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	f := &gitFixture{t: t, dir: t.TempDir()}
+	f.git(time.Time{}, "init", "--quiet")
+	f.git(time.Time{}, "config", "user.name", "Test")
+	f.git(time.Time{}, "config", "user.email", "test@example.com")
+
+	return f
+}
+
+func (f *gitFixture) git(date time.Time, args ...string) {
+	f.t.Helper()
+
+	cmd := exec.Command("git", args...)
+	cmd.Dir = f.dir
+	cmd.Env = append(os.Environ(), "GIT_CONFIG_GLOBAL=/dev/null", "GIT_CONFIG_NOSYSTEM=1")
+	if !date.IsZero() {
+		cmd.Env = append(cmd.Env,
+			"GIT_AUTHOR_DATE="+date.Format(time.RFC3339),
+			"GIT_COMMITTER_DATE="+date.Format(time.RFC3339),
+		)
+	}
+
+	if output, err := cmd.CombinedOutput(); err != nil {
+		f.t.Fatalf("git %v failed, err: %v, output: %s", args, err, output)
+	}
+}
+
+func (f *gitFixture) write(path string, content string) {
+	f.t.Helper()
+
+	path = filepath.Join(f.dir, path)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		f.t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+func (f *gitFixture) commit(date time.Time, message string) {
+	f.t.Helper()
+
+	f.git(time.Time{}, "add", "--all")
+	f.git(date, "commit", "--quiet", "-m", message)
+}
+
+func TestReadGitHistory(t *testing.T) {
+	f := newGitFixture(t)
+
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2023, month, d, 14, 30, 0, 0, time.UTC)
+	}
+
+	f.write("pages/about.md", "About\n")
+	f.write("pages/blog/first.md", "First post\n\nWith enough content to be detected as renamed.\n")
+	f.write("pages/deleted.md", "Deleted\n")
+	f.write("README.md", "Outside of the pages\n")
+	f.commit(day(time.January, 10), "initial")
+
+	f.write("pages/about.md", "About me\n")
+	f.commit(day(time.February, 5), "update about")
+
+	f.git(time.Time{}, "mv", "pages/blog/first.md", "pages/blog/first-post.md")
+	f.commit(day(time.March, 1), "rename first post")
+
+	f.git(time.Time{}, "rm", "--quiet", "pages/deleted.md")
+	f.commit(day(time.April, 1), "delete page")
+
+	f.write("pages/deleted.md", "Recreated\n")
+	f.commit(day(time.May, 1), "recreate page")
+
+	history, err := readGitHistory(context.Background(), filepath.Join(f.dir, "pages"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if history.prefix != "pages/" {
+		t.Errorf("got prefix %q, want %q", history.prefix, "pages/")
+	}
+
+	want := map[string]gitFileHistory{
+		"about.md":           {Created: day(time.January, 10), Updated: day(time.February, 5)},
+		"blog/first-post.md": {Created: day(time.January, 10), Updated: day(time.March, 1)},
+		"deleted.md":         {Created: day(time.May, 1), Updated: day(time.May, 1)},
+	}
+	if len(history.files) != len(want) {
+		t.Errorf("got files %v, want %v", history.files, want)
+	}
+	for path, wantHistory := range want {
+		got, ok := history.files[path]
+		if !ok {
+			t.Errorf("missing history of %s", path)
+			continue
+		}
+		if !got.Created.Equal(wantHistory.Created) || !got.Updated.Equal(wantHistory.Updated) {
+			t.Errorf("got history of %s created %s updated %s, want created %s updated %s",
+				path, got.Created, got.Updated, wantHistory.Created, wantHistory.Updated)
+		}
+	}
+}
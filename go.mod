@@ -4,15 +4,18 @@ go 1.24
 
 require (
 	github.com/a-h/templ v0.3.960
+	github.com/go-pdf/fpdf v0.9.0
+	github.com/kljensen/snowball v0.10.0
 	github.com/spf13/cobra v1.9.1
 	github.com/yuin/goldmark v1.7.13
 	github.com/yuin/goldmark-meta v1.1.0
 	go.abhg.dev/goldmark/toc v0.12.0
 	go.uber.org/multierr v1.11.0
+	golang.org/x/image v0.25.0
+	gopkg.in/yaml.v2 v2.4.0
 )
 
 require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
-	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
@@ -0,0 +1,296 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+// imagePipelineVersion is part of the cache key of the processed images.
+//
+// It must be incremented whenever the processing changes, to invalidate the cache.
+const imagePipelineVersion = 1
+
+// responsiveImageWidths are the widths of the resized variants of the images.
+//
+// Only the widths smaller than the original image are generated, see [imageVariantWidths].
+var responsiveImageWidths = []int{480, 960, 1440}
+
+const jpegQuality = 85
+
+// imageVariantWidths returns the widths of the variants generated for an image of the given width.
+func imageVariantWidths(width int) []int {
+	var res []int
+	for _, w := range responsiveImageWidths {
+		if w < width {
+			res = append(res, w)
+		}
+	}
+	return res
+}
+
+// imageVariantName returns the name of the variant of an image for the given width.
+func imageVariantName(name string, width int) string {
+	ext := filepath.Ext(name)
+	return fmt.Sprintf("%s-%dw%s", strings.TrimSuffix(name, ext), width, ext)
+}
+
+// imagePipeline processes the PNG and JPEG images:
+// * the original image is re-encoded and stripped of its metadata
+// * smaller variants are generated for each width in [responsiveImageWidths]
+//
+// Processed images are cached by content hash in cacheDir.
+type imagePipeline struct {
+	logger   *slog.Logger
+	cacheDir string
+}
+
+func (p *imagePipeline) handles(ext string) bool {
+	switch strings.ToLower(ext) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	default:
+		return false
+	}
+}
+
+// process processes the image at inputPath and writes the results in the outputDir directory of the build directory.
+func (p *imagePipeline) process(inputPath string, buildRootDir string, outputDir string, generationDate time.Time) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("unable to read image %q, err: %w", inputPath, err)
+	}
+
+	// Get the processed images, from the cache if possible
+
+	key := imageCacheKey(data)
+	entryDir := filepath.Join(p.cacheDir, key)
+
+	outputs, err := readImageCacheEntry(entryDir)
+	if err != nil {
+		return err
+	}
+	if outputs == nil {
+		p.logger.Info("processing image", slog.String("path", inputPath))
+
+		outputs, err = processImage(data, filepath.Ext(inputPath))
+		if err != nil {
+			return fmt.Errorf("unable to process image %q, err: %w", inputPath, err)
+		}
+
+		if err := writeImageCacheEntry(entryDir, outputs); err != nil {
+			return err
+		}
+	} else {
+		p.logger.Debug("using cached image", slog.String("path", inputPath), slog.String("key", key))
+	}
+
+	// Write the images
+
+	name := filepath.Base(inputPath)
+	for label, data := range outputs {
+		outputName := name
+		if label != imageCacheOriginalLabel {
+			width, err := strconv.Atoi(strings.TrimSuffix(label, "w"))
+			if err != nil {
+				return fmt.Errorf("invalid cached image %q, err: %w", label, err)
+			}
+			outputName = imageVariantName(name, width)
+		}
+
+		if !generationDate.IsZero() {
+			outputName, _ = renameWithVersion(outputName, generationDate)
+		}
+
+		if err := writeOutputFile(buildRootDir, filepath.Join(outputDir, outputName), string(data)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func imageCacheKey(data []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "v%d %v %d\n", imagePipelineVersion, responsiveImageWidths, jpegQuality)
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// imageCacheOriginalLabel is the label of the full size image in a cache entry.
+// Variants are labeled with their width, for example `480w`.
+const imageCacheOriginalLabel = "original"
+
+// readImageCacheEntry returns the images stored in a cache entry, or nil if the entry doesn't exist.
+func readImageCacheEntry(dir string) (map[string][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read image cache entry %q, err: %w", dir, err)
+	}
+
+	res := make(map[string][]byte, len(entries))
+	for _, entry := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read image cache entry %q, err: %w", dir, err)
+		}
+		res[entry.Name()] = data
+	}
+
+	return res, nil
+}
+
+// writeImageCacheEntry stores images in a cache entry.
+//
+// The images are written in a temporary directory renamed at the end, so that an interrupted build never leaves a partial entry.
+func writeImageCacheEntry(dir string, images map[string][]byte) error {
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return fmt.Errorf("unable to create image cache directory, err: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dir), ".tmp-")
+	if err != nil {
+		return fmt.Errorf("unable to create image cache entry, err: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	for label, data := range images {
+		if err := os.WriteFile(filepath.Join(tmpDir, label), data, 0644); err != nil {
+			return fmt.Errorf("unable to write image cache entry, err: %w", err)
+		}
+	}
+
+	if err := os.Rename(tmpDir, dir); err != nil {
+		return fmt.Errorf("unable to write image cache entry, err: %w", err)
+	}
+
+	return nil
+}
+
+// processImage re-encodes the image and generates its resized variants.
+func processImage(data []byte, ext string) (map[string][]byte, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	encode := func(img image.Image) ([]byte, error) {
+		var buf bytes.Buffer
+		var err error
+		switch format {
+		case "png":
+			encoder := png.Encoder{CompressionLevel: png.BestCompression}
+			err = encoder.Encode(&buf, img)
+		case "jpeg":
+			err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: jpegQuality})
+		default:
+			err = fmt.Errorf("unsupported image format %q for extension %q", format, ext)
+		}
+		return buf.Bytes(), err
+	}
+
+	res := make(map[string][]byte)
+
+	// Re-encode the original image, this strips the metadata
+	{
+		encoded, err := encode(img)
+		if err != nil {
+			return nil, err
+		}
+
+		// The Go PNG encoder isn't always better than the original encoder, keep the original without its metadata if it's smaller
+		if format == "png" {
+			if stripped, err := stripPNGMetadata(data); err == nil && len(stripped) < len(encoded) {
+				encoded = stripped
+			}
+		}
+
+		res[imageCacheOriginalLabel] = encoded
+	}
+
+	// Generate the variants
+	bounds := img.Bounds()
+	for _, width := range imageVariantWidths(bounds.Dx()) {
+		height := bounds.Dy() * width / bounds.Dx()
+
+		resized := image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.CatmullRom.Scale(resized, resized.Bounds(), img, bounds, draw.Src, nil)
+
+		encoded, err := encode(resized)
+		if err != nil {
+			return nil, err
+		}
+
+		res[strconv.Itoa(width)+"w"] = encoded
+	}
+
+	return res, nil
+}
+
+// pngAncillaryChunksToKeep are the ancillary PNG chunks affecting how the image is displayed.
+// All other ancillary chunks, like text or timestamps, are metadata.
+var pngAncillaryChunksToKeep = map[string]struct{}{
+	"tRNS": {},
+	"gAMA": {},
+	"cHRM": {},
+	"sRGB": {},
+	"iCCP": {},
+	"sBIT": {},
+	"pHYs": {},
+}
+
+// stripPNGMetadata removes the metadata chunks of a PNG image.
+func stripPNGMetadata(data []byte) ([]byte, error) {
+	const signature = "\x89PNG\r\n\x1a\n"
+
+	if !bytes.HasPrefix(data, []byte(signature)) {
+		return nil, errors.New("not a PNG image")
+	}
+
+	res := bytes.NewBuffer(make([]byte, 0, len(data)))
+	res.WriteString(signature)
+
+	for rest := data[len(signature):]; len(rest) > 0; {
+		if len(rest) < 12 {
+			return nil, errors.New("truncated PNG chunk")
+		}
+
+		length := int(binary.BigEndian.Uint32(rest))
+		if len(rest) < 12+length {
+			return nil, errors.New("truncated PNG chunk")
+		}
+		chunk := rest[:12+length]
+		rest = rest[12+length:]
+
+		chunkType := string(chunk[4:8])
+		if crc32.ChecksumIEEE(chunk[4:8+length]) != binary.BigEndian.Uint32(chunk[8+length:]) {
+			return nil, fmt.Errorf("invalid CRC for PNG chunk %s", chunkType)
+		}
+
+		// Critical chunks start with an uppercase letter
+		_, keep := pngAncillaryChunksToKeep[chunkType]
+		if chunkType[0] >= 'A' && chunkType[0] <= 'Z' || keep {
+			res.Write(chunk)
+		}
+	}
+
+	return res.Bytes(), nil
+}
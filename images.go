@@ -0,0 +1,245 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	goldmarkast "github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	goldmarkrenderer "github.com/yuin/goldmark/renderer"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	goldmarktext "github.com/yuin/goldmark/text"
+	goldmarkutil "github.com/yuin/goldmark/util"
+)
+
+// imageFallbackAttribute is the image node attribute containing the destination of the fallback image.
+//
+// It's used by [pictureRenderer] and is never rendered as is.
+const imageFallbackAttribute = "fallback"
+
+// responsiveImageTransformer is a goldmarkast.ASTTransformer that adds the information needed to render responsive images:
+// * the intrinsic width and height of the image, read from the image file
+// * the fallback PNG image for AVIF images, if it exists next to the AVIF file
+// * the resized variants of PNG and JPEG images, generated by [imagePipeline]
+// * lazy loading and asynchronous decoding
+//
+// It must run after [imagePathTransformer] because it needs absolute destinations to find the image files.
+type responsiveImageTransformer struct {
+	pagesDir  string
+	assetsDir string
+}
+
+func newResponsiveImageTransformer(pagesDir, assetsDir string) *responsiveImageTransformer {
+	return &responsiveImageTransformer{
+		pagesDir:  pagesDir,
+		assetsDir: assetsDir,
+	}
+}
+
+func (t *responsiveImageTransformer) Transform(node *goldmarkast.Document, reader goldmarktext.Reader, pc goldmarkparser.Context) {
+	goldmarkast.Walk(node, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+		img, ok := n.(*goldmarkast.Image)
+		if !ok || !entering {
+			return goldmarkast.WalkContinue, nil
+		}
+
+		destination := string(img.Destination)
+		if !strings.HasPrefix(destination, "/") {
+			// Remote image
+			return goldmarkast.WalkContinue, nil
+		}
+
+		filename := t.localPath(destination)
+
+		// Find the fallback
+		dimensionsFilename := filename
+		if path.Ext(destination) == ".avif" {
+			fallback := strings.TrimSuffix(destination, ".avif") + ".png"
+			fallbackFilename := t.localPath(fallback)
+
+			if _, err := os.Stat(fallbackFilename); err == nil {
+				img.SetAttributeString(imageFallbackAttribute, []byte(fallback))
+				dimensionsFilename = fallbackFilename
+			}
+		}
+
+		// Read the dimensions
+		width, height, err := readImageDimensions(dimensionsFilename)
+		if err != nil {
+			addTransformError(pc, fmt.Errorf("unable to read dimensions of image %q, err: %w", destination, err))
+			return goldmarkast.WalkContinue, nil
+		}
+
+		img.SetAttributeString("width", []byte(strconv.Itoa(width)))
+		img.SetAttributeString("height", []byte(strconv.Itoa(height)))
+
+		// Add the resized variants generated by imagePipeline
+		raster := destination
+		if fallback, ok := img.AttributeString(imageFallbackAttribute); ok {
+			raster = string(fallback.([]byte))
+		}
+		if variantWidths := imageVariantWidths(width); len(variantWidths) > 0 && isRasterImage(raster) {
+			var srcset []string
+			for _, variantWidth := range variantWidths {
+				srcset = append(srcset, fmt.Sprintf("%s %dw", imageVariantName(raster, variantWidth), variantWidth))
+			}
+			srcset = append(srcset, fmt.Sprintf("%s %dw", raster, width))
+
+			img.SetAttributeString("srcset", []byte(strings.Join(srcset, ", ")))
+			img.SetAttributeString("sizes", []byte(fmt.Sprintf("(max-width: %dpx) 100vw, %dpx", width, width)))
+		}
+
+		img.SetAttributeString("loading", []byte("lazy"))
+		img.SetAttributeString("decoding", []byte("async"))
+
+		return goldmarkast.WalkContinue, nil
+	})
+}
+
+func isRasterImage(destination string) bool {
+	switch path.Ext(destination) {
+	case ".png", ".jpg", ".jpeg":
+		return true
+	default:
+		return false
+	}
+}
+
+// localPath returns the path of the file served at the URL path destination.
+func (t *responsiveImageTransformer) localPath(destination string) string {
+	if rest, ok := strings.CutPrefix(destination, "/assets/"); ok {
+		return filepath.Join(t.assetsDir, filepath.FromSlash(rest))
+	}
+	return filepath.Join(t.pagesDir, filepath.FromSlash(destination))
+}
+
+var _ goldmarkparser.ASTTransformer = (*responsiveImageTransformer)(nil)
+
+// readImageDimensions reads the width and height of an image from its header.
+//
+// PNG, JPEG and AVIF images are supported. The format is detected from the content, not the extension.
+func readImageDimensions(filename string) (int, int, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+
+	// AVIF files start with a `ftyp` box
+	if magic, err := r.Peek(12); err == nil && bytes.Equal(magic[4:8], []byte("ftyp")) {
+		return readAVIFDimensions(r)
+	}
+
+	config, _, err := image.DecodeConfig(r)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return config.Width, config.Height, nil
+}
+
+// readAVIFDimensions reads the width and height of an AVIF image from its `ispe` (image spatial extents) property.
+//
+// The `ispe` box is in the metadata at the beginning of the file, it contains a version and flags
+// followed by the width and height as big endian 32 bits integers.
+func readAVIFDimensions(r io.Reader) (int, int, error) {
+	header := make([]byte, 64*1024)
+	n, err := io.ReadFull(r, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, 0, err
+	}
+	header = header[:n]
+
+	i := bytes.Index(header, []byte("ispe"))
+	if i < 0 || len(header) < i+16 {
+		return 0, 0, fmt.Errorf("no ispe box found, %w", fs.ErrInvalid)
+	}
+
+	width := binary.BigEndian.Uint32(header[i+8:])
+	height := binary.BigEndian.Uint32(header[i+12:])
+
+	return int(width), int(height), nil
+}
+
+// pictureRenderer is a goldmarkrenderer.NodeRenderer rendering images with a fallback as a `<picture>` element.
+//
+// Images without a fallback are rendered as a `<img>` element.
+type pictureRenderer struct {
+	goldmarkhtml.Config
+}
+
+func newPictureRenderer() *pictureRenderer {
+	return &pictureRenderer{
+		Config: goldmarkhtml.NewConfig(),
+	}
+}
+
+func (r *pictureRenderer) SetOption(name goldmarkrenderer.OptionName, value any) {
+	r.Config.SetOption(name, value)
+}
+
+func (r *pictureRenderer) RegisterFuncs(reg goldmarkrenderer.NodeRendererFuncRegisterer) {
+	reg.Register(goldmarkast.KindImage, r.renderImage)
+}
+
+func (r *pictureRenderer) renderImage(w goldmarkutil.BufWriter, source []byte, node goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+	if !entering {
+		return goldmarkast.WalkContinue, nil
+	}
+	n := node.(*goldmarkast.Image)
+
+	src := n.Destination
+
+	fallback, hasFallback := n.AttributeString(imageFallbackAttribute)
+	if hasFallback {
+		_, _ = w.WriteString(`<picture><source srcset="`)
+		_, _ = w.Write(goldmarkutil.EscapeHTML(goldmarkutil.URLEscape(n.Destination, true)))
+		_, _ = w.WriteString(`" type="image/avif">`)
+
+		src = fallback.([]byte)
+	}
+
+	_, _ = w.WriteString(`<img src="`)
+	if r.Unsafe || !goldmarkhtml.IsDangerousURL(src) {
+		_, _ = w.Write(goldmarkutil.EscapeHTML(goldmarkutil.URLEscape(src, true)))
+	}
+	_, _ = w.WriteString(`" alt="`)
+	_, _ = w.Write(goldmarkutil.EscapeHTML([]byte(extractText(n, source))))
+	_ = w.WriteByte('"')
+	if n.Title != nil {
+		_, _ = w.WriteString(` title="`)
+		r.Writer.Write(w, n.Title)
+		_ = w.WriteByte('"')
+	}
+	if n.Attributes() != nil {
+		goldmarkhtml.RenderAttributes(w, n, goldmarkhtml.ImageAttributeFilter)
+	}
+	if r.XHTML {
+		_, _ = w.WriteString(" />")
+	} else {
+		_, _ = w.WriteString(">")
+	}
+
+	if hasFallback {
+		_, _ = w.WriteString("</picture>")
+	}
+
+	return goldmarkast.WalkSkipChildren, nil
+}
+
+var _ goldmarkrenderer.NodeRenderer = (*pictureRenderer)(nil)
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	goldmarkast "github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	goldmarkrenderer "github.com/yuin/goldmark/renderer"
+	goldmarktext "github.com/yuin/goldmark/text"
+	goldmarkutil "github.com/yuin/goldmark/util"
+)
+
+// includedCodeBlockNode is a fenced code block whose content is read from a file, see [includeTransformer].
+type includedCodeBlockNode struct {
+	goldmarkast.BaseBlock
+
+	Language []byte
+	Code     []byte
+}
+
+var kindIncludedCodeBlock = goldmarkast.NewNodeKind("IncludedCodeBlock")
+
+func (n *includedCodeBlockNode) Kind() goldmarkast.NodeKind { return kindIncludedCodeBlock }
+
+func (n *includedCodeBlockNode) Dump(source []byte, level int) {
+	goldmarkast.DumpHelper(n, source, level, map[string]string{
+		"Language": string(n.Language),
+	}, nil)
+}
+
+var (
+	includeAttributesRegexp = regexp.MustCompile(`\{(.*)\}\s*$`)
+	includeAttributeRegexp  = regexp.MustCompile(`([a-z]+)="([^"]*)"`)
+	includeLinesRegexp      = regexp.MustCompile(`^(\d+)(?:-(\d*))?$`)
+)
+
+// includeTransformer is a goldmarkast.ASTTransformer that replaces the content of fenced code blocks with an `include` attribute
+// with the content of a file, optionally restricted to a range of lines:
+//
+//	```go {include="snippets/main.go" lines="10-30"}
+//	```
+//
+// The file path is relative to the directory of the page. The range of lines is inclusive, the end can be omitted to include
+// everything up to the end of the file, like `lines="10-"`.
+//
+// A missing file or an invalid range is an error.
+type includeTransformer struct {
+	pagesDir string
+}
+
+func newIncludeTransformer(pagesDir string) *includeTransformer {
+	return &includeTransformer{
+		pagesDir: pagesDir,
+	}
+}
+
+func (t *includeTransformer) Transform(node *goldmarkast.Document, reader goldmarktext.Reader, pc goldmarkparser.Context) {
+	sourcePath, ok := pc.Get(sourcePathContextKey).(string)
+	if !ok {
+		return
+	}
+	source := reader.Source()
+
+	var blocks []*goldmarkast.FencedCodeBlock
+
+	goldmarkast.Walk(node, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+		if block, ok := n.(*goldmarkast.FencedCodeBlock); ok && entering && block.Info != nil {
+			blocks = append(blocks, block)
+		}
+		return goldmarkast.WalkContinue, nil
+	})
+
+	for _, block := range blocks {
+		info := block.Info.Segment.Value(source)
+
+		matches := includeAttributesRegexp.FindSubmatch(info)
+		if matches == nil {
+			continue
+		}
+
+		attributes := make(map[string]string)
+		for _, attribute := range includeAttributeRegexp.FindAllSubmatch(matches[1], -1) {
+			attributes[string(attribute[1])] = string(attribute[2])
+		}
+
+		include, ok := attributes["include"]
+		if !ok {
+			continue
+		}
+
+		line := lineNumber(source, block.Info.Segment.Start)
+
+		code, err := t.readInclude(sourcePath, include, attributes)
+		if err != nil {
+			addTransformError(pc, fmt.Errorf("line %d: %w", line, err))
+			continue
+		}
+		if block.Lines().Len() > 0 {
+			addTransformError(pc, fmt.Errorf("line %d: a code block with an `include` attribute must be empty", line))
+			continue
+		}
+
+		// The language is the first word of the info, if it's not the attributes
+		language, _, _ := bytes.Cut(info, []byte(" "))
+		if bytes.HasPrefix(language, []byte("{")) {
+			language = nil
+		}
+
+		included := &includedCodeBlockNode{
+			Language: language,
+			Code:     code,
+		}
+		block.Parent().ReplaceChild(block.Parent(), block, included)
+	}
+}
+
+func (t *includeTransformer) readInclude(sourcePath string, include string, attributes map[string]string) ([]byte, error) {
+	for name := range attributes {
+		if name != "include" && name != "lines" {
+			return nil, fmt.Errorf("invalid code block attribute %q, should be one of \"include\" or \"lines\"", name)
+		}
+	}
+
+	// The included file must be in the pages directory, otherwise any file could end up in the website
+	relativePath := filepath.Join(filepath.Dir(filepath.FromSlash(sourcePath)), filepath.FromSlash(include))
+	if path.IsAbs(include) || !filepath.IsLocal(relativePath) {
+		return nil, fmt.Errorf("invalid `include` value %q, should be the path of a file in the pages directory relative to the page", include)
+	}
+
+	filename := filepath.Join(t.pagesDir, relativePath)
+
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("unable to include file %q, err: %w", include, err)
+	}
+
+	linesRange, ok := attributes["lines"]
+	if !ok {
+		return data, nil
+	}
+
+	matches := includeLinesRegexp.FindStringSubmatch(linesRange)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid `lines` value %q, should be a range like `10-30` or `10-`", linesRange)
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	start, _ := strconv.Atoi(matches[1])
+	end := start
+	if matches[0] != matches[1] {
+		end = len(lines)
+		if matches[2] != "" {
+			end, _ = strconv.Atoi(matches[2])
+		}
+	}
+
+	if start < 1 || start > end || end > len(lines) {
+		return nil, fmt.Errorf("invalid `lines` value %q for file %q which has %d lines", linesRange, include, len(lines))
+	}
+
+	return []byte(strings.Join(lines[start-1:end], "")), nil
+}
+
+var _ goldmarkparser.ASTTransformer = (*includeTransformer)(nil)
+
+// includedCodeBlockRenderer is a goldmarkrenderer.NodeRenderer rendering an [includedCodeBlockNode] like a fenced code block.
+type includedCodeBlockRenderer struct{}
+
+func (r includedCodeBlockRenderer) RegisterFuncs(reg goldmarkrenderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindIncludedCodeBlock, r.renderIncludedCodeBlock)
+}
+
+func (r includedCodeBlockRenderer) renderIncludedCodeBlock(w goldmarkutil.BufWriter, source []byte, node goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+	if !entering {
+		return goldmarkast.WalkContinue, nil
+	}
+	n := node.(*includedCodeBlockNode)
+
+	_, _ = w.WriteString("<pre><code")
+	if len(n.Language) > 0 {
+		_, _ = w.WriteString(` class="language-`)
+		_, _ = w.Write(goldmarkutil.EscapeHTML(n.Language))
+		_ = w.WriteByte('"')
+	}
+	_ = w.WriteByte('>')
+	_, _ = w.Write(goldmarkutil.EscapeHTML(n.Code))
+	_, _ = w.WriteString("</code></pre>\n")
+
+	return goldmarkast.WalkSkipChildren, nil
+}
+
+var _ goldmarkrenderer.NodeRenderer = includedCodeBlockRenderer{}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadInclude(t *testing.T) {
+	root := t.TempDir()
+	pagesDir := filepath.Join(root, "pages")
+
+	for name, content := range map[string]string{
+		"pages/code/_snippets/main.go": "package main\n\nfunc main() {}\n",
+		"pages/shared/lib.go":          "package lib\n",
+		"secret.txt":                   "secret\n",
+	} {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	transformer := newIncludeTransformer(pagesDir)
+
+	testCases := []struct {
+		name       string
+		include    string
+		attributes map[string]string
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:    "file next to the page",
+			include: "_snippets/main.go",
+			want:    "package main\n\nfunc main() {}\n",
+		},
+		{
+			name:       "lines",
+			include:    "_snippets/main.go",
+			attributes: map[string]string{"lines": "3-"},
+			want:       "func main() {}\n",
+		},
+		{
+			name:    "file in another directory of the pages",
+			include: "../shared/lib.go",
+			want:    "package lib\n",
+		},
+		{
+			name:    "file outside of the pages",
+			include: "../../secret.txt",
+			wantErr: true,
+		},
+		{
+			name:    "traversal to the root",
+			include: "../../../../../../../../etc/passwd",
+			wantErr: true,
+		},
+		{
+			name:    "absolute path",
+			include: "/etc/passwd",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			attributes := map[string]string{"include": tc.include}
+			for name, value := range tc.attributes {
+				attributes[name] = value
+			}
+
+			data, err := transformer.readInclude("code/page.md", tc.include, attributes)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", data)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tc.want {
+				t.Errorf("got %q, want %q", data, tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strings"
+
+	goldmarkast "github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	goldmarktext "github.com/yuin/goldmark/text"
+	"go.uber.org/multierr"
+)
+
+// transformErrorContextKey is used by transformers run with [transformPages] to report errors.
+var transformErrorContextKey = goldmarkparser.NewContextKey()
+
+func addTransformError(pc goldmarkparser.Context, err error) {
+	prev, _ := pc.Get(transformErrorContextKey).(error)
+	pc.Set(transformErrorContextKey, multierr.Append(prev, err))
+}
+
+// lineNumber returns the line number of a position in the source, for error messages.
+func lineNumber(source []byte, offset int) int {
+	return bytes.Count(source[:offset], []byte("\n")) + 1
+}
+
+// transformPages runs transformers on the markdown document of every page.
+//
+// This is for transformers which need to know about every page, like resolving links between pages:
+// they can't be run by the parser because the pages are not all collected at this point.
+func transformPages(pages pages, transformers ...goldmarkparser.ASTTransformer) error {
+	for _, page := range pages {
+		document, ok := page.markdownDocument.(*goldmarkast.Document)
+		if !ok {
+			continue
+		}
+
+		pc := goldmarkparser.NewContext()
+		pc.Set(sourcePathContextKey, page.sourcePath)
+
+		reader := goldmarktext.NewReader(page.sourceData)
+		for _, transformer := range transformers {
+			transformer.Transform(document, reader, pc)
+		}
+
+		if err, ok := pc.Get(transformErrorContextKey).(error); ok && err != nil {
+			return fmt.Errorf("unable to transform page %s, err: %w", page.sourcePath, err)
+		}
+	}
+
+	return nil
+}
+
+// pageLinkTransformer is a goldmarkast.ASTTransformer that changes links to markdown files to the URL of the target page.
+//
+// This allows writing links like `[envconfig](./code/envconfig.md)` which work both in editors and on the website.
+// A link to a markdown file which is not a collected page is an error, and so is a link to a file with multiple notes
+// since it doesn't point to a single page.
+type pageLinkTransformer struct {
+	pageURLs map[string]string // page source path -> page URL
+	// multiplePages are the source paths of the files with multiple pages
+	multiplePages map[string]struct{}
+}
+
+func newPageLinkTransformer(pages pages) *pageLinkTransformer {
+	res := &pageLinkTransformer{
+		pageURLs:      make(map[string]string, len(pages)),
+		multiplePages: make(map[string]struct{}),
+	}
+	for _, page := range pages {
+		if _, ok := res.pageURLs[page.sourcePath]; ok {
+			res.multiplePages[page.sourcePath] = struct{}{}
+		}
+		res.pageURLs[page.sourcePath] = page.url()
+	}
+	return res
+}
+
+func (t *pageLinkTransformer) Transform(node *goldmarkast.Document, reader goldmarktext.Reader, pc goldmarkparser.Context) {
+	sourcePath, _ := pc.Get(sourcePathContextKey).(string)
+
+	goldmarkast.Walk(node, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+		link, ok := n.(*goldmarkast.Link)
+		if !ok || !entering {
+			return goldmarkast.WalkContinue, nil
+		}
+
+		destination := string(link.Destination)
+		if strings.Contains(destination, ":") {
+			// Has a scheme, not a link to a page
+			return goldmarkast.WalkContinue, nil
+		}
+
+		target, fragment, hasFragment := strings.Cut(destination, "#")
+		if path.Ext(target) != ".md" {
+			return goldmarkast.WalkContinue, nil
+		}
+
+		// Absolute links are relative to the pages root directory
+		if strings.HasPrefix(target, "/") {
+			target = path.Clean(target[1:])
+		} else {
+			target = path.Join(path.Dir(sourcePath), target)
+		}
+
+		url, ok := t.pageURLs[target]
+		if !ok {
+			addTransformError(pc, fmt.Errorf("link to %q points to page %s which doesn't exist", destination, target))
+			return goldmarkast.WalkContinue, nil
+		}
+		if _, ok := t.multiplePages[target]; ok {
+			addTransformError(pc, fmt.Errorf("link to %q points to %s which contains multiple notes, link to the URL of a note instead", destination, target))
+			return goldmarkast.WalkContinue, nil
+		}
+
+		if hasFragment {
+			url += "#" + fragment
+		}
+		link.Destination = []byte(url)
+
+		return goldmarkast.WalkContinue, nil
+	})
+}
+
+var _ goldmarkparser.ASTTransformer = (*pageLinkTransformer)(nil)
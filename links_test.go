@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yuin/goldmark"
+	goldmarkast "github.com/yuin/goldmark/ast"
+)
+
+// collectTestPages writes files, a map of source path to content, in a temporary pages directory and collects them.
+func collectTestPages(t *testing.T, files map[string]string) (pages, *generateCommandConfig) {
+	t.Helper()
+
+	cfg := &generateCommandConfig{pagesDir: t.TempDir()}
+	for sourcePath, content := range files {
+		path := filepath.Join(cfg.pagesDir, filepath.FromSlash(sourcePath))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	parsers := newMarkdownParsers(func(options markdownOptions) goldmark.Markdown {
+		return cfg.newMarkdown(time.Time{}, options)
+	})
+
+	res, err := collectPages(cfg.pagesDir, parsers, defaultPermalinks, generatedPagePaths, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return res, cfg
+}
+
+// renderTestPage renders the page with the given source path, collected with [collectTestPages].
+func renderTestPage(t *testing.T, cfg *generateCommandConfig, pages pages, sourcePath string) string {
+	t.Helper()
+
+	renderer := cfg.newMarkdown(time.Time{}, defaultMarkdownOptions).Renderer()
+	for _, page := range pages {
+		if page.sourcePath != sourcePath {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := renderer.Render(&buf, page.sourceData, page.markdownDocument); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	t.Fatalf("page %s not found", sourcePath)
+	return ""
+}
+
+func TestLinksToMultipleNotes(t *testing.T) {
+	const notes = "---\nformat: note\ndate: 2024 March 02\n---\nFirst.\n---\ndate: 2024 March 05\n---\nSecond.\n"
+
+	testCases := []struct {
+		name   string
+		source string
+	}{
+		{name: "markdown link", source: "See [notes](/notes/2024.md).\n"},
+		{name: "wiki link", source: "See [[notes/2024]].\n"},
+		{name: "wiki link by name", source: "See [[2024]].\n"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			pages, _ := collectTestPages(t, map[string]string{
+				"notes/2024.md": notes,
+				"about.md":      tc.source,
+			})
+
+			err := transformPages(pages, newWikiLinkTransformer(pages), newPageLinkTransformer(pages))
+			if err == nil || !strings.Contains(err.Error(), "multiple notes") {
+				t.Fatalf("expected an error about multiple notes, got %v", err)
+			}
+		})
+	}
+}
+
+func TestPageLinkTransformer(t *testing.T) {
+	files := map[string]string{
+		"about.md":             "---\nformat: standard\n---\nAbout.\n",
+		"code/envconfig.md":    "---\nformat: standard\npermalink: /projects/:slug\n---\nEnvconfig.\n",
+		"code/zig-sqlite.md":   "---\nformat: standard\n---\nZig SQLite.\n",
+		"blog/2024/post.md":    "---\nformat: blog_entry\ntitle: Post\ndate: 2024 March 02\n---\nPost.\n",
+		"blog/2024/drafts.txt": "not a page\n",
+	}
+
+	testCases := []struct {
+		name    string
+		link    string
+		want    string
+		wantErr string
+	}{
+		{name: "relative", link: "./zig-sqlite.md", want: "/code/zig-sqlite"},
+		{name: "relative without dot", link: "zig-sqlite.md", want: "/code/zig-sqlite"},
+		{name: "permalink", link: "envconfig.md", want: "/projects/envconfig"},
+		{name: "fragment", link: "zig-sqlite.md#usage", want: "/code/zig-sqlite#usage"},
+		{name: "parent directory", link: "../about.md", want: "/about"},
+		{name: "absolute", link: "/blog/2024/post.md#intro", want: "/blog/post#intro"},
+		{name: "not markdown", link: "../blog/2024/drafts.txt", want: "../blog/2024/drafts.txt"},
+		{name: "url", link: "https://example.com/readme.md", want: "https://example.com/readme.md"},
+		{name: "fragment only", link: "#usage", want: "#usage"},
+		{name: "missing page", link: "./missing.md#usage", wantErr: "points to page code/missing.md which doesn't exist"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sources := maps.Clone(files)
+			sources["code/index.md"] = "---\nformat: standard\n---\nSee [the link](" + tc.link + ").\n"
+			pages, _ := collectTestPages(t, sources)
+
+			err := transformPages(pages, newPageLinkTransformer(pages))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want an error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var destination string
+			for _, page := range pages {
+				if page.sourcePath != "code/index.md" {
+					continue
+				}
+				goldmarkast.Walk(page.markdownDocument, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+					if link, ok := n.(*goldmarkast.Link); ok && entering {
+						destination = string(link.Destination)
+					}
+					return goldmarkast.WalkContinue, nil
+				})
+			}
+			if destination != tc.want {
+				t.Errorf("got destination %q, want %q", destination, tc.want)
+			}
+		})
+	}
+}
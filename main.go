@@ -19,6 +19,7 @@ func main() {
 	}
 
 	rootCmd.AddCommand(newGenerateCmd(logger))
+	rootCmd.AddCommand(newResumePDFCmd(logger))
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintf(os.Stderr, "%v\n", err)
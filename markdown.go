@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yuin/goldmark"
+	goldmarkmeta "github.com/yuin/goldmark-meta"
+	goldmarkextension "github.com/yuin/goldmark/extension"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	goldmarkrenderer "github.com/yuin/goldmark/renderer"
+	goldmarkhtml "github.com/yuin/goldmark/renderer/html"
+	goldmarkutil "github.com/yuin/goldmark/util"
+)
+
+// markdownOptions are the optional markdown extensions, configurable per page with the `markdown` front matter value:
+//
+//	markdown:
+//	  typographer: false
+type markdownOptions struct {
+	// GFM enables the GitHub Flavored Markdown extensions: tables, strikethrough, task lists and autolinks.
+	GFM             bool
+	Footnotes       bool
+	DefinitionLists bool
+	// Typographer replaces quotes, dashes and ellipses with their typographic equivalent.
+	Typographer bool
+}
+
+var defaultMarkdownOptions = markdownOptions{
+	GFM:             true,
+	Footnotes:       true,
+	DefinitionLists: true,
+	Typographer:     true,
+}
+
+func parseMarkdownOptions(value any) (markdownOptions, error) {
+	res := defaultMarkdownOptions
+
+	values, ok := value.(map[any]any)
+	if !ok {
+		return markdownOptions{}, fmt.Errorf("invalid `markdown` value %v, should be a map", value)
+	}
+
+	for key, value := range values {
+		enabled, ok := value.(bool)
+		if !ok {
+			return markdownOptions{}, fmt.Errorf("invalid `markdown.%v` value %v, should be a boolean", key, value)
+		}
+
+		switch key {
+		case "gfm":
+			res.GFM = enabled
+		case "footnotes":
+			res.Footnotes = enabled
+		case "definition_lists":
+			res.DefinitionLists = enabled
+		case "typographer":
+			res.Typographer = enabled
+		default:
+			return markdownOptions{}, fmt.Errorf("invalid `markdown` key %v, should be one of \"gfm\", \"footnotes\", \"definition_lists\" or \"typographer\"", key)
+		}
+	}
+
+	return res, nil
+}
+
+// newMarkdown creates the goldmark instance used to parse and render the pages.
+func (c *generateCommandConfig) newMarkdown(generationDate time.Time, options markdownOptions) goldmark.Markdown {
+	extensions := []goldmark.Extender{
+		goldmarkmeta.Meta,
+		wikiLinkExtension{},
+		figureExtension{},
+		admonitionExtension{},
+		mathExtension{},
+		shortcodeExtension{},
+	}
+	if options.GFM {
+		extensions = append(extensions, goldmarkextension.GFM)
+	}
+	if options.Footnotes {
+		extensions = append(extensions, goldmarkextension.Footnote)
+	}
+	if options.DefinitionLists {
+		extensions = append(extensions, goldmarkextension.DefinitionList)
+	}
+	if options.Typographer {
+		extensions = append(extensions, goldmarkextension.Typographer)
+	}
+
+	return goldmark.New(
+		goldmark.WithParserOptions(
+			goldmarkparser.WithAutoHeadingID(),
+			goldmarkparser.WithASTTransformers(
+				goldmarkutil.Prioritized(newIncludeTransformer(c.pagesDir), 5),
+				goldmarkutil.Prioritized(imagePathTransformer{}, 10),
+				goldmarkutil.Prioritized(newResponsiveImageTransformer(c.pagesDir, c.assetsDir), 50),
+				goldmarkutil.Prioritized(newImageVersioningTransformer(generationDate), 100),
+			),
+		),
+		goldmark.WithRendererOptions(
+			goldmarkhtml.WithUnsafe(),
+			goldmarkrenderer.WithNodeRenderers(
+				goldmarkutil.Prioritized(newPictureRenderer(), 500),
+				goldmarkutil.Prioritized(includedCodeBlockRenderer{}, 500),
+			),
+		),
+		goldmark.WithExtensions(extensions...),
+	)
+}
+
+// markdownParsers creates and caches a parser for each combination of markdown options used by the pages.
+type markdownParsers struct {
+	newMarkdown func(options markdownOptions) goldmark.Markdown
+	parsers     map[markdownOptions]goldmarkparser.Parser
+}
+
+func newMarkdownParsers(newMarkdown func(options markdownOptions) goldmark.Markdown) *markdownParsers {
+	return &markdownParsers{
+		newMarkdown: newMarkdown,
+		parsers:     make(map[markdownOptions]goldmarkparser.Parser),
+	}
+}
+
+func (p *markdownParsers) get(options markdownOptions) goldmarkparser.Parser {
+	parser, ok := p.parsers[options]
+	if !ok {
+		parser = p.newMarkdown(options).Parser()
+		p.parsers[options] = parser
+	}
+	return parser
+}
@@ -0,0 +1,27 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/yuin/goldmark"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+)
+
+// convertMarkdown converts source to HTML with the given extensions, returning the errors reported by the parser.
+func convertMarkdown(t *testing.T, source string, extensions ...goldmark.Extender) (string, error) {
+	t.Helper()
+
+	md := goldmark.New(goldmark.WithExtensions(extensions...))
+
+	pc := goldmarkparser.NewContext()
+	var buf bytes.Buffer
+	if err := md.Convert([]byte(source), &buf, goldmarkparser.WithContext(pc)); err != nil {
+		t.Fatal(err)
+	}
+	if err, ok := pc.Get(transformErrorContextKey).(error); ok && err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/yuin/goldmark"
+	goldmarkast "github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	goldmarkrenderer "github.com/yuin/goldmark/renderer"
+	goldmarktext "github.com/yuin/goldmark/text"
+	goldmarkutil "github.com/yuin/goldmark/util"
+)
+
+// mathNode is a `$inline$` or `$$display$$` math expression inside a paragraph.
+//
+// The math is converted to MathML by the parser, see [texToMathML].
+type mathNode struct {
+	goldmarkast.BaseInline
+
+	TeX     string
+	Display bool
+	MathML  string
+}
+
+var kindMath = goldmarkast.NewNodeKind("Math")
+
+func (n *mathNode) Kind() goldmarkast.NodeKind { return kindMath }
+
+func (n *mathNode) Dump(source []byte, level int) {
+	goldmarkast.DumpHelper(n, source, level, map[string]string{
+		"TeX": n.TeX,
+	}, nil)
+}
+
+// mathBlockNode is a display math block delimited by `$$` lines.
+type mathBlockNode struct {
+	goldmarkast.BaseBlock
+
+	TeX    []byte
+	MathML string
+
+	closed bool
+}
+
+var kindMathBlock = goldmarkast.NewNodeKind("MathBlock")
+
+func (n *mathBlockNode) Kind() goldmarkast.NodeKind { return kindMathBlock }
+
+func (n *mathBlockNode) Dump(source []byte, level int) {
+	goldmarkast.DumpHelper(n, source, level, map[string]string{
+		"TeX": string(n.TeX),
+	}, nil)
+}
+
+// mathInlineParser is a goldmarkparser.InlineParser parsing `$inline$` and `$$display$$` math.
+//
+// Like in pandoc, the opening `$` of inline math must be followed by a non space character and the closing `$`
+// must be preceded by a non space character and not followed by a digit, so that prices like $5 and $10 are left alone.
+type mathInlineParser struct{}
+
+func (p mathInlineParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p mathInlineParser) Parse(parent goldmarkast.Node, block goldmarktext.Reader, pc goldmarkparser.Context) goldmarkast.Node {
+	line, _ := block.PeekLine()
+
+	delimiter := []byte("$")
+	if bytes.HasPrefix(line, []byte("$$")) {
+		delimiter = []byte("$$")
+	}
+
+	rest := line[len(delimiter):]
+	end := indexUnescaped(rest, delimiter)
+	if end <= 0 {
+		return nil
+	}
+	tex := rest[:end]
+
+	display := len(delimiter) == 2
+	if !display {
+		if isSpaceByte(tex[0]) || isSpaceByte(tex[len(tex)-1]) {
+			return nil
+		}
+		if after := rest[end+1:]; len(after) > 0 && isASCIIDigit(after[0]) {
+			return nil
+		}
+	}
+
+	block.Advance(len(delimiter)*2 + end)
+
+	node := &mathNode{
+		TeX:     string(tex),
+		Display: display,
+	}
+
+	mathML, err := texToMathML(node.TeX, display)
+	if err != nil {
+		addTransformError(pc, err)
+	}
+	node.MathML = mathML
+
+	return node
+}
+
+var _ goldmarkparser.InlineParser = mathInlineParser{}
+
+// indexUnescaped returns the index of the first delimiter in data not preceded by a backslash, or -1.
+func indexUnescaped(data []byte, delimiter []byte) int {
+	for i := 0; i+len(delimiter) <= len(data); i++ {
+		if data[i] == '\\' {
+			i++
+			continue
+		}
+		if bytes.HasPrefix(data[i:], delimiter) {
+			return i
+		}
+	}
+	return -1
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// mathBlockParser is a goldmarkparser.BlockParser parsing display math blocks:
+//
+//	$$
+//	\sum_{i=1}^{n} cost(i)
+//	$$
+//
+// The opening `$$` must be alone on its line, unless the block fits on a single line like `$$ x^2 $$`.
+type mathBlockParser struct{}
+
+func (p mathBlockParser) Trigger() []byte {
+	return []byte{'$'}
+}
+
+func (p mathBlockParser) Open(parent goldmarkast.Node, reader goldmarktext.Reader, pc goldmarkparser.Context) (goldmarkast.Node, goldmarkparser.State) {
+	line, segment := reader.PeekLine()
+	if pc.BlockOffset() < 0 {
+		// Indented code block
+		return nil, goldmarkparser.NoChildren
+	}
+
+	trimmed := bytes.TrimSpace(line)
+	if !bytes.HasPrefix(trimmed, []byte("$$")) {
+		return nil, goldmarkparser.NoChildren
+	}
+	rest := trimmed[2:]
+
+	node := &mathBlockNode{}
+
+	switch content, ok := bytes.CutSuffix(rest, []byte("$$")); {
+	case ok:
+		// Single line block, anything else like `$$a$$ and $$b$$` is inline math
+		if bytes.Contains(content, []byte("$$")) {
+			return nil, goldmarkparser.NoChildren
+		}
+		node.TeX = content
+		node.closed = true
+	case len(rest) > 0:
+		// A multi line block starts with `$$` alone on its line, anything else like `$$a$$ and more text` is inline math
+		return nil, goldmarkparser.NoChildren
+	}
+
+	reader.Advance(lineLengthWithoutNewline(line, segment))
+
+	return node, goldmarkparser.NoChildren
+}
+
+func (p mathBlockParser) Continue(node goldmarkast.Node, reader goldmarktext.Reader, pc goldmarkparser.Context) goldmarkparser.State {
+	n := node.(*mathBlockNode)
+	if n.closed {
+		return goldmarkparser.Close
+	}
+
+	line, segment := reader.PeekLine()
+	if line == nil {
+		return goldmarkparser.Close
+	}
+
+	if content, ok := bytes.CutSuffix(bytes.TrimSpace(line), []byte("$$")); ok {
+		n.TeX = append(n.TeX, content...)
+		n.closed = true
+		reader.Advance(lineLengthWithoutNewline(line, segment))
+		return goldmarkparser.Close
+	}
+
+	n.TeX = append(n.TeX, line...)
+	reader.Advance(lineLengthWithoutNewline(line, segment))
+
+	return goldmarkparser.Continue | goldmarkparser.NoChildren
+}
+
+func (p mathBlockParser) Close(node goldmarkast.Node, reader goldmarktext.Reader, pc goldmarkparser.Context) {
+	n := node.(*mathBlockNode)
+
+	if !n.closed {
+		addTransformError(pc, errors.New("unclosed `$$` math block"))
+		return
+	}
+
+	mathML, err := texToMathML(string(bytes.TrimSpace(n.TeX)), true)
+	if err != nil {
+		addTransformError(pc, err)
+	}
+	n.MathML = mathML
+}
+
+func (p mathBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p mathBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+var _ goldmarkparser.BlockParser = mathBlockParser{}
+
+// lineLengthWithoutNewline returns the length to advance to consume a line, the parser consumes the newline itself.
+func lineLengthWithoutNewline(line []byte, segment goldmarktext.Segment) int {
+	if bytes.HasSuffix(line, []byte("\n")) {
+		return segment.Len() - 1
+	}
+	return segment.Len()
+}
+
+// mathRenderer is a goldmarkrenderer.NodeRenderer rendering the MathML of [mathNode] and [mathBlockNode].
+type mathRenderer struct{}
+
+func (r mathRenderer) RegisterFuncs(reg goldmarkrenderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindMath, r.renderMath)
+	reg.Register(kindMathBlock, r.renderMathBlock)
+}
+
+func (r mathRenderer) renderMath(w goldmarkutil.BufWriter, source []byte, node goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(node.(*mathNode).MathML)
+	}
+	return goldmarkast.WalkSkipChildren, nil
+}
+
+func (r mathRenderer) renderMathBlock(w goldmarkutil.BufWriter, source []byte, node goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+	if entering {
+		_, _ = w.WriteString(node.(*mathBlockNode).MathML)
+		_ = w.WriteByte('\n')
+	}
+	return goldmarkast.WalkSkipChildren, nil
+}
+
+var _ goldmarkrenderer.NodeRenderer = mathRenderer{}
+
+// mathExtension adds math rendered to MathML at build time.
+type mathExtension struct{}
+
+func (e mathExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		goldmarkparser.WithBlockParsers(
+			goldmarkutil.Prioritized(mathBlockParser{}, 650),
+		),
+		goldmarkparser.WithInlineParsers(
+			goldmarkutil.Prioritized(mathInlineParser{}, 500),
+		),
+	)
+	m.Renderer().AddOptions(
+		goldmarkrenderer.WithNodeRenderers(
+			goldmarkutil.Prioritized(mathRenderer{}, 500),
+		),
+	)
+}
+
+var _ goldmark.Extender = mathExtension{}
+
+// hasMath returns true if the document contains math, in which case the page needs the math stylesheet.
+func hasMath(document goldmarkast.Node) bool {
+	var res bool
+	goldmarkast.Walk(document, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+		if n.Kind() == kindMath || n.Kind() == kindMathBlock {
+			res = true
+			return goldmarkast.WalkStop, nil
+		}
+		return goldmarkast.WalkContinue, nil
+	})
+	return res
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTeXToMathMLNonASCIIDigits(t *testing.T) {
+	for _, tex := range []string{"١", "１+1", "2١3"} {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _ = texToMathML(tex, false)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("converting %q to MathML doesn't terminate", tex)
+		}
+	}
+}
+
+// mathMLContent returns the content of the `<semantics>` element of a MathML element without its TeX annotation.
+func mathMLContent(t *testing.T, mathML string) string {
+	t.Helper()
+
+	_, content, ok := strings.Cut(mathML, "<semantics>")
+	if !ok {
+		t.Fatalf("no semantics element in %s", mathML)
+	}
+	content, _, ok = strings.Cut(content, "<annotation")
+	if !ok {
+		t.Fatalf("no annotation element in %s", mathML)
+	}
+	return content
+}
+
+func TestTeXToMathML(t *testing.T) {
+	testCases := []struct {
+		tex     string
+		display bool
+		want    string
+	}{
+		{tex: `x`, want: `<mi>x</mi>`},
+		{tex: `12.5`, want: `<mn>12.5</mn>`},
+		{tex: `a+b=c`, want: `<mrow><mi>a</mi><mo>+</mo><mi>b</mi><mo>=</mo><mi>c</mi></mrow>`},
+		{tex: `x^2`, want: `<msup><mi>x</mi><mn>2</mn></msup>`},
+		{tex: `x_i`, want: `<msub><mi>x</mi><mi>i</mi></msub>`},
+		{tex: `x_i^2`, want: `<msubsup><mi>x</mi><mi>i</mi><mn>2</mn></msubsup>`},
+		{tex: `x^{2n}`, want: `<msup><mi>x</mi><mrow><mn>2</mn><mi>n</mi></mrow></msup>`},
+		{tex: `\frac{a}{b}`, want: `<mfrac><mi>a</mi><mi>b</mi></mfrac>`},
+		{tex: `\sqrt{x}`, want: `<msqrt><mi>x</mi></msqrt>`},
+		{tex: `\sqrt[3]{x}`, want: `<mroot><mi>x</mi><mn>3</mn></mroot>`},
+		{
+			tex:  `\sum_{i=1}^{n} i`,
+			want: `<mrow><msubsup><mo movablelimits="true">∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></msubsup><mi>i</mi></mrow>`,
+		},
+		{
+			tex:     `\sum_{i=1}^{n} i`,
+			display: true,
+			want:    `<mrow><munderover><mo movablelimits="true">∑</mo><mrow><mi>i</mi><mo>=</mo><mn>1</mn></mrow><mi>n</mi></munderover><mi>i</mi></mrow>`,
+		},
+		{
+			tex:  `\left( x \right)`,
+			want: `<mrow><mo fence="true" stretchy="true">(</mo><mi>x</mi><mo fence="true" stretchy="true">)</mo></mrow>`,
+		},
+		{tex: `\left. x \right|`, want: `<mrow><mi>x</mi><mo fence="true" stretchy="true">|</mo></mrow>`},
+		{tex: `\alpha \leq \infty`, want: `<mrow><mi>α</mi><mo>≤</mo><mi>∞</mi></mrow>`},
+		{tex: `\mathbb{R}`, want: `<mi mathvariant="double-struck">R</mi>`},
+		{tex: `\text{if } x`, want: `<mrow><mtext>if </mtext><mi>x</mi></mrow>`},
+		{tex: `\hat{x}`, want: `<mover accent="true"><mi>x</mi><mo stretchy="true">^</mo></mover>`},
+		{
+			tex:  `\begin{matrix} a & b \\ c & d \end{matrix}`,
+			want: `<mtable><mtr><mtd><mi>a</mi></mtd><mtd><mi>b</mi></mtd></mtr><mtr><mtd><mi>c</mi></mtd><mtd><mi>d</mi></mtd></mtr></mtable>`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.tex, func(t *testing.T) {
+			output, err := texToMathML(tc.tex, tc.display)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.Contains(output, `display="block"`); got != tc.display {
+				t.Errorf("got display %v, want %v, output: %s", got, tc.display, output)
+			}
+			if got := mathMLContent(t, output); got != tc.want {
+				t.Errorf("got %s\nwant %s", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTeXToMathMLErrors(t *testing.T) {
+	testCases := []struct {
+		tex     string
+		wantErr string
+	}{
+		{tex: `\foo`, wantErr: `unknown command \foo`},
+		{tex: `\frac{a}`, wantErr: "missing argument"},
+		{tex: `{x`, wantErr: "missing }"},
+		{tex: `\left( x`, wantErr: `missing \right`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.tex, func(t *testing.T) {
+			_, err := texToMathML(tc.tex, false)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("got error %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestMathBlock(t *testing.T) {
+	testCases := []struct {
+		name   string
+		source string
+		// block is true if the math is a block, otherwise it's inline math in a paragraph
+		block        bool
+		wantContains []string
+	}{
+		{
+			name:   "multi line",
+			source: "$$\nx^2\n$$\n\nAfter\n",
+			block:  true,
+			wantContains: []string{
+				"<p>After</p>",
+			},
+		},
+		{
+			name:   "single line",
+			source: "$$ x^2 $$\n\nAfter\n",
+			block:  true,
+			wantContains: []string{
+				"<p>After</p>",
+			},
+		},
+		{
+			name:   "trailing text",
+			source: "$$x$$ trailing text\n\nNext paragraph\n",
+			block:  false,
+			wantContains: []string{
+				"trailing text</p>",
+				"<p>Next paragraph</p>",
+			},
+		},
+		{
+			name:   "inline math on the same line",
+			source: "$$a$$ and $$b$$\n\nNext paragraph\n",
+			block:  false,
+			wantContains: []string{
+				"<p>Next paragraph</p>",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := convertMarkdown(t, tc.source, mathExtension{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := strings.HasPrefix(output, "<math"); got != tc.block {
+				t.Errorf("got math block %v, want %v, output: %s", got, tc.block, output)
+			}
+			for _, want := range tc.wantContains {
+				if !strings.Contains(output, want) {
+					t.Errorf("output doesn't contain %q, output: %s", want, output)
+				}
+			}
+		})
+	}
+}
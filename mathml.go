@@ -0,0 +1,702 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// This file implements the conversion of TeX math to MathML.
+//
+// Only the subset of TeX commonly used in KaTeX is supported: letters, numbers and operators,
+// superscripts and subscripts, fractions, roots, the usual symbols, fonts, accents, delimiters
+// and the matrix, cases and aligned environments. An unknown command is an error.
+
+type texTokenKind int
+
+const (
+	texTokenChar texTokenKind = iota
+	texTokenNumber
+	texTokenCommand
+	texTokenSpace
+	texTokenBeginGroup
+	texTokenEndGroup
+	texTokenSuperscript
+	texTokenSubscript
+	texTokenAlign
+)
+
+type texToken struct {
+	kind  texTokenKind
+	value string
+}
+
+func tokenizeTeX(tex string) []texToken {
+	var res []texToken
+
+	for i := 0; i < len(tex); {
+		r, size := utf8.DecodeRuneInString(tex[i:])
+
+		switch {
+		case r == '\\':
+			j := i + 1
+			for j < len(tex) && isASCIILetter(tex[j]) {
+				j++
+			}
+			if j == i+1 && j < len(tex) {
+				// Single character command like `\,` or `\{`
+				_, size := utf8.DecodeRuneInString(tex[j:])
+				j += size
+			}
+			res = append(res, texToken{texTokenCommand, tex[i+1 : j]})
+			i = j
+			continue
+
+		case unicode.IsSpace(r):
+			res = append(res, texToken{texTokenSpace, " "})
+		case r == '{':
+			res = append(res, texToken{texTokenBeginGroup, "{"})
+		case r == '}':
+			res = append(res, texToken{texTokenEndGroup, "}"})
+		case r == '^':
+			res = append(res, texToken{texTokenSuperscript, "^"})
+		case r == '_':
+			res = append(res, texToken{texTokenSubscript, "_"})
+		case r == '&':
+			res = append(res, texToken{texTokenAlign, "&"})
+
+		case isASCIIDigit(tex[i]):
+			j := i
+			for j < len(tex) && (isASCIIDigit(tex[j]) || tex[j] == '.' && j+1 < len(tex) && isASCIIDigit(tex[j+1])) {
+				j++
+			}
+			res = append(res, texToken{texTokenNumber, tex[i:j]})
+			i = j
+			continue
+
+		default:
+			res = append(res, texToken{texTokenChar, string(r)})
+		}
+
+		i += size
+	}
+
+	return res
+}
+
+func isASCIILetter(c byte) bool { return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' }
+func isASCIIDigit(c byte) bool  { return c >= '0' && c <= '9' }
+
+// texSymbol is a command producing a single MathML token element.
+type texSymbol struct {
+	tag   string // mi or mo
+	value string
+	// limits is true for operators whose scripts are placed above and below in display mode
+	limits bool
+}
+
+var texSymbols = map[string]texSymbol{}
+
+func init() {
+	for name, value := range map[string]string{
+		"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ϵ", "varepsilon": "ε", "zeta": "ζ",
+		"eta": "η", "theta": "θ", "vartheta": "ϑ", "iota": "ι", "kappa": "κ", "lambda": "λ", "mu": "μ",
+		"nu": "ν", "xi": "ξ", "pi": "π", "varpi": "ϖ", "rho": "ρ", "varrho": "ϱ", "sigma": "σ", "varsigma": "ς",
+		"tau": "τ", "upsilon": "υ", "phi": "ϕ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+		"infty": "∞", "partial": "∂", "nabla": "∇", "emptyset": "∅", "varnothing": "∅", "ell": "ℓ", "hbar": "ℏ",
+	} {
+		texSymbols[name] = texSymbol{tag: "mi", value: value}
+	}
+
+	for name, value := range map[string]string{
+		"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+		"Sigma": "Σ", "Upsilon": "Υ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+	} {
+		// Upper case greek letters are upright, the mathvariant is added by texParser.identifier
+		texSymbols[name] = texSymbol{tag: "mi", value: value}
+	}
+
+	for name, value := range map[string]string{
+		"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "ne": "≠", "neq": "≠", "ll": "≪", "gg": "≫",
+		"approx": "≈", "sim": "∼", "simeq": "≃", "equiv": "≡", "propto": "∝",
+		"times": "×", "cdot": "⋅", "div": "÷", "pm": "±", "mp": "∓", "ast": "∗", "star": "⋆", "circ": "∘", "bullet": "∙",
+		"in": "∈", "notin": "∉", "ni": "∋", "subset": "⊂", "subseteq": "⊆", "supset": "⊃", "supseteq": "⊇",
+		"cup": "∪", "cap": "∩", "setminus": "∖", "oplus": "⊕", "otimes": "⊗",
+		"forall": "∀", "exists": "∃", "neg": "¬", "lnot": "¬", "land": "∧", "wedge": "∧", "lor": "∨", "vee": "∨",
+		"to": "→", "rightarrow": "→", "leftarrow": "←", "gets": "←", "leftrightarrow": "↔",
+		"Rightarrow": "⇒", "Leftarrow": "⇐", "Leftrightarrow": "⇔", "implies": "⟹", "iff": "⟺", "mapsto": "↦",
+		"ldots": "…", "dots": "…", "cdots": "⋯", "vdots": "⋮", "ddots": "⋱",
+		"mid": "∣", "parallel": "∥", "perp": "⊥", "colon": ":", "prime": "′",
+		"langle": "⟨", "rangle": "⟩", "lfloor": "⌊", "rfloor": "⌋", "lceil": "⌈", "rceil": "⌉",
+		"vert": "|", "lvert": "|", "rvert": "|", "Vert": "‖", "lVert": "‖", "rVert": "‖",
+		"{": "{", "}": "}", "|": "‖", "%": "%", "$": "$", "&": "&", "#": "#", "_": "_",
+		"int": "∫", "iint": "∬", "iiint": "∭", "oint": "∮",
+	} {
+		texSymbols[name] = texSymbol{tag: "mo", value: value}
+	}
+
+	for name, value := range map[string]string{
+		"sum": "∑", "prod": "∏", "coprod": "∐", "bigcup": "⋃", "bigcap": "⋂",
+		"bigvee": "⋁", "bigwedge": "⋀", "bigoplus": "⨁", "bigotimes": "⨂",
+	} {
+		texSymbols[name] = texSymbol{tag: "mo", value: value, limits: true}
+	}
+
+	for _, name := range []string{
+		"sin", "cos", "tan", "cot", "sec", "csc", "arcsin", "arccos", "arctan", "sinh", "cosh", "tanh",
+		"log", "ln", "lg", "exp", "deg", "det", "dim", "ker", "gcd", "arg", "hom",
+	} {
+		texSymbols[name] = texSymbol{tag: "mi", value: name}
+	}
+
+	for _, name := range []string{"lim", "max", "min", "sup", "inf", "Pr"} {
+		texSymbols[name] = texSymbol{tag: "mi", value: name, limits: true}
+	}
+}
+
+var texSpaces = map[string]string{
+	",":       "0.1667em",
+	":":       "0.2222em",
+	";":       "0.2778em",
+	"!":       "-0.1667em",
+	" ":       "0.3333em",
+	"quad":    "1em",
+	"qquad":   "2em",
+	"enspace": "0.5em",
+}
+
+var texFonts = map[string]string{
+	"mathrm":     "normal",
+	"mathbf":     "bold",
+	"mathit":     "italic",
+	"mathbb":     "double-struck",
+	"mathcal":    "script",
+	"mathsf":     "sans-serif",
+	"mathtt":     "monospace",
+	"boldsymbol": "bold-italic",
+}
+
+var texAccents = map[string]string{
+	"hat":       "^",
+	"widehat":   "^",
+	"bar":       "¯",
+	"overline":  "‾",
+	"vec":       "→",
+	"tilde":     "~",
+	"widetilde": "~",
+	"dot":       "˙",
+	"ddot":      "¨",
+}
+
+// texEnvironments maps an environment to its opening and closing delimiters and its columns alignment.
+var texEnvironments = map[string]struct {
+	open, close string
+	columnAlign string
+}{
+	"matrix":  {"", "", ""},
+	"pmatrix": {"(", ")", ""},
+	"bmatrix": {"[", "]", ""},
+	"vmatrix": {"|", "|", ""},
+	"cases":   {"{", "", "left left"},
+	"aligned": {"", "", "right left"},
+}
+
+type texParser struct {
+	tokens  []texToken
+	pos     int
+	display bool
+	// variant is the mathvariant set by a font command like `\mathbf`
+	variant string
+}
+
+// texToMathML converts TeX math to a MathML `<math>` element.
+//
+// The TeX source is kept in an annotation like KaTeX does, so it can be copied.
+func texToMathML(tex string, display bool) (string, error) {
+	p := &texParser{
+		tokens:  tokenizeTeX(tex),
+		display: display,
+	}
+
+	row, err := p.parseRow(nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid math %q, err: %w", tex, err)
+	}
+	if p.pos < len(p.tokens) {
+		return "", fmt.Errorf("invalid math %q, err: unexpected %q", tex, p.tokens[p.pos].value)
+	}
+
+	var buf strings.Builder
+	if display {
+		buf.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML" display="block">`)
+	} else {
+		buf.WriteString(`<math xmlns="http://www.w3.org/1998/Math/MathML">`)
+	}
+	buf.WriteString("<semantics>")
+	buf.WriteString(mrow(row))
+	buf.WriteString(`<annotation encoding="application/x-tex">`)
+	buf.WriteString(html.EscapeString(tex))
+	buf.WriteString("</annotation></semantics></math>")
+
+	return buf.String(), nil
+}
+
+func mrow(elements []string) string {
+	if len(elements) == 1 {
+		return elements[0]
+	}
+	return "<mrow>" + strings.Join(elements, "") + "</mrow>"
+}
+
+func mathMLElement(tag string, attrs string, content string) string {
+	if attrs != "" {
+		return "<" + tag + " " + attrs + ">" + content + "</" + tag + ">"
+	}
+	return "<" + tag + ">" + content + "</" + tag + ">"
+}
+
+func (p *texParser) peek() (texToken, bool) {
+	for p.pos < len(p.tokens) && p.tokens[p.pos].kind == texTokenSpace {
+		p.pos++
+	}
+	if p.pos >= len(p.tokens) {
+		return texToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *texParser) next() (texToken, bool) {
+	token, ok := p.peek()
+	if ok {
+		p.pos++
+	}
+	return token, ok
+}
+
+// parseRow parses elements until the end of the input or until stop returns true.
+// The stop token is not consumed.
+func (p *texParser) parseRow(stop func(texToken) bool) ([]string, error) {
+	var res []string
+	for {
+		token, ok := p.peek()
+		if !ok || stop != nil && stop(token) {
+			return res, nil
+		}
+		if token.kind == texTokenEndGroup {
+			return nil, fmt.Errorf("unexpected %q", token.value)
+		}
+
+		element, err := p.parseScripted()
+		if err != nil {
+			return nil, err
+		}
+		if element != "" {
+			res = append(res, element)
+		}
+	}
+}
+
+// parseScripted parses an atom followed by its superscript and subscript.
+func (p *texParser) parseScripted() (string, error) {
+	base, limits, err := p.parseAtom()
+	if err != nil {
+		return "", err
+	}
+
+	var sub, sup, primes string
+	for {
+		token, ok := p.peek()
+		if !ok {
+			break
+		}
+
+		if token.kind == texTokenChar && token.value == "'" {
+			p.pos++
+			primes += "<mo>′</mo>"
+			continue
+		}
+
+		if token.kind != texTokenSuperscript && token.kind != texTokenSubscript {
+			break
+		}
+		p.pos++
+
+		script, err := p.parseArgument()
+		if err != nil {
+			return "", err
+		}
+
+		if token.kind == texTokenSuperscript {
+			if sup != "" {
+				return "", fmt.Errorf("double superscript")
+			}
+			sup = script
+		} else {
+			if sub != "" {
+				return "", fmt.Errorf("double subscript")
+			}
+			sub = script
+		}
+	}
+
+	switch {
+	case primes != "" && sup != "":
+		sup = "<mrow>" + primes + sup + "</mrow>"
+	case strings.Count(primes, "<mo>") > 1:
+		sup = "<mrow>" + primes + "</mrow>"
+	case primes != "":
+		sup = primes
+	}
+
+	switch {
+	case sub == "" && sup == "":
+		return base, nil
+	case limits && p.display && sup == "":
+		return mathMLElement("munder", "", base+sub), nil
+	case limits && p.display && sub == "":
+		return mathMLElement("mover", "", base+sup), nil
+	case limits && p.display:
+		return mathMLElement("munderover", "", base+sub+sup), nil
+	case sup == "":
+		return mathMLElement("msub", "", base+sub), nil
+	case sub == "":
+		return mathMLElement("msup", "", base+sup), nil
+	default:
+		return mathMLElement("msubsup", "", base+sub+sup), nil
+	}
+}
+
+// parseArgument parses a group or a single atom, like the argument of a command or a script.
+func (p *texParser) parseArgument() (string, error) {
+	token, ok := p.peek()
+	if !ok {
+		return "", fmt.Errorf("missing argument")
+	}
+
+	if token.kind == texTokenBeginGroup {
+		p.pos++
+		row, err := p.parseGroupContent()
+		if err != nil {
+			return "", err
+		}
+		return mrow(row), nil
+	}
+
+	// A number argument is a single digit, like in `\frac12`
+	if token.kind == texTokenNumber && len(token.value) > 1 {
+		p.tokens[p.pos].value = token.value[1:]
+		return p.number(token.value[:1]), nil
+	}
+
+	atom, _, err := p.parseAtom()
+	return atom, err
+}
+
+func (p *texParser) parseGroupContent() ([]string, error) {
+	row, err := p.parseRow(func(token texToken) bool { return token.kind == texTokenEndGroup })
+	if err != nil {
+		return nil, err
+	}
+	if token, ok := p.next(); !ok || token.kind != texTokenEndGroup {
+		return nil, fmt.Errorf("missing }")
+	}
+	return row, nil
+}
+
+// parseRawGroup returns the raw content of a group, used for text and environment names.
+func (p *texParser) parseRawGroup() (string, error) {
+	if token, ok := p.next(); !ok || token.kind != texTokenBeginGroup {
+		return "", fmt.Errorf("missing {")
+	}
+
+	var buf strings.Builder
+	for depth := 0; p.pos < len(p.tokens); p.pos++ {
+		token := p.tokens[p.pos]
+		switch token.kind {
+		case texTokenBeginGroup:
+			depth++
+		case texTokenEndGroup:
+			if depth == 0 {
+				p.pos++
+				return buf.String(), nil
+			}
+			depth--
+		case texTokenCommand:
+			// Escaped characters in text, like `\%`
+			if symbol, ok := texSymbols[token.value]; ok && len(token.value) == 1 {
+				buf.WriteString(symbol.value)
+				continue
+			}
+			buf.WriteString(`\` + token.value)
+			continue
+		}
+		buf.WriteString(token.value)
+	}
+
+	return "", fmt.Errorf("missing }")
+}
+
+func (p *texParser) identifier(value string) string {
+	attrs := ""
+	switch {
+	case p.variant != "":
+		attrs = fmt.Sprintf(`mathvariant=%q`, p.variant)
+	case utf8.RuneCountInString(value) == 1 && unicode.IsUpper([]rune(value)[0]) && unicode.Is(unicode.Greek, []rune(value)[0]):
+		attrs = `mathvariant="normal"`
+	}
+	return mathMLElement("mi", attrs, html.EscapeString(value))
+}
+
+func (p *texParser) number(value string) string {
+	attrs := ""
+	if p.variant != "" && p.variant != "normal" {
+		attrs = fmt.Sprintf(`mathvariant=%q`, p.variant)
+	}
+	return mathMLElement("mn", attrs, value)
+}
+
+func operator(value string) string {
+	return mathMLElement("mo", "", html.EscapeString(value))
+}
+
+// parseAtom parses a single element. limits is true if the scripts of the element go above and below in display mode.
+func (p *texParser) parseAtom() (res string, limits bool, err error) {
+	token, ok := p.next()
+	if !ok {
+		return "", false, fmt.Errorf("unexpected end of input")
+	}
+
+	switch token.kind {
+	case texTokenNumber:
+		return p.number(token.value), false, nil
+
+	case texTokenChar:
+		r, _ := utf8.DecodeRuneInString(token.value)
+		if unicode.IsLetter(r) {
+			return p.identifier(token.value), false, nil
+		}
+		return operator(token.value), false, nil
+
+	case texTokenBeginGroup:
+		row, err := p.parseGroupContent()
+		if err != nil {
+			return "", false, err
+		}
+		return mrow(row), false, nil
+
+	case texTokenCommand:
+		return p.parseCommand(token.value)
+
+	default:
+		return "", false, fmt.Errorf("unexpected %q", token.value)
+	}
+}
+
+func (p *texParser) parseCommand(name string) (string, bool, error) {
+	if symbol, ok := texSymbols[name]; ok {
+		if symbol.tag == "mi" {
+			if utf8.RuneCountInString(symbol.value) > 1 {
+				// Function names are upright
+				return mathMLElement("mi", "", symbol.value), symbol.limits, nil
+			}
+			return p.identifier(symbol.value), symbol.limits, nil
+		}
+		if symbol.limits {
+			return mathMLElement("mo", `movablelimits="true"`, symbol.value), true, nil
+		}
+		return operator(symbol.value), false, nil
+	}
+
+	if width, ok := texSpaces[name]; ok {
+		return fmt.Sprintf(`<mspace width="%s"></mspace>`, width), false, nil
+	}
+
+	if variant, ok := texFonts[name]; ok {
+		prev := p.variant
+		p.variant = variant
+		defer func() { p.variant = prev }()
+
+		argument, err := p.parseArgument()
+		return argument, false, err
+	}
+
+	if accent, ok := texAccents[name]; ok {
+		argument, err := p.parseArgument()
+		if err != nil {
+			return "", false, err
+		}
+		return mathMLElement("mover", `accent="true"`, argument+mathMLElement("mo", `stretchy="true"`, accent)), false, nil
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		numerator, err := p.parseArgument()
+		if err != nil {
+			return "", false, err
+		}
+		denominator, err := p.parseArgument()
+		if err != nil {
+			return "", false, err
+		}
+		return mathMLElement("mfrac", "", numerator+denominator), false, nil
+
+	case "sqrt":
+		// Optional index, like `\sqrt[3]{x}`
+		var index string
+		if token, ok := p.peek(); ok && token.kind == texTokenChar && token.value == "[" {
+			p.pos++
+			row, err := p.parseRow(func(token texToken) bool { return token.kind == texTokenChar && token.value == "]" })
+			if err != nil {
+				return "", false, err
+			}
+			if _, ok := p.next(); !ok {
+				return "", false, fmt.Errorf("missing ]")
+			}
+			index = mrow(row)
+		}
+
+		radicand, err := p.parseArgument()
+		if err != nil {
+			return "", false, err
+		}
+		if index != "" {
+			return mathMLElement("mroot", "", radicand+index), false, nil
+		}
+		return mathMLElement("msqrt", "", radicand), false, nil
+
+	case "text", "textrm", "mbox":
+		text, err := p.parseRawGroup()
+		if err != nil {
+			return "", false, err
+		}
+		return mathMLElement("mtext", "", html.EscapeString(text)), false, nil
+
+	case "operatorname":
+		text, err := p.parseRawGroup()
+		if err != nil {
+			return "", false, err
+		}
+		return mathMLElement("mi", "", html.EscapeString(text)), false, nil
+
+	case "left":
+		open, err := p.parseDelimiter()
+		if err != nil {
+			return "", false, err
+		}
+		row, err := p.parseRow(func(token texToken) bool { return token.kind == texTokenCommand && token.value == "right" })
+		if err != nil {
+			return "", false, err
+		}
+		if _, ok := p.next(); !ok {
+			return "", false, fmt.Errorf(`missing \right`)
+		}
+		close, err := p.parseDelimiter()
+		if err != nil {
+			return "", false, err
+		}
+		return "<mrow>" + fence(open) + strings.Join(row, "") + fence(close) + "</mrow>", false, nil
+
+	case "begin":
+		res, err := p.parseEnvironment()
+		return res, false, err
+
+	default:
+		return "", false, fmt.Errorf(`unknown command \%s`, name)
+	}
+}
+
+func fence(delimiter string) string {
+	if delimiter == "" {
+		return ""
+	}
+	return mathMLElement("mo", `fence="true" stretchy="true"`, html.EscapeString(delimiter))
+}
+
+// parseDelimiter parses the delimiter after `\left` or `\right`, `.` is an empty delimiter.
+func (p *texParser) parseDelimiter() (string, error) {
+	token, ok := p.next()
+	if !ok {
+		return "", fmt.Errorf("missing delimiter")
+	}
+
+	switch token.kind {
+	case texTokenChar:
+		if token.value == "." {
+			return "", nil
+		}
+		return token.value, nil
+	case texTokenCommand:
+		if symbol, ok := texSymbols[token.value]; ok && symbol.tag == "mo" {
+			return symbol.value, nil
+		}
+	}
+
+	return "", fmt.Errorf("invalid delimiter %q", token.value)
+}
+
+func (p *texParser) parseEnvironment() (string, error) {
+	name, err := p.parseRawGroup()
+	if err != nil {
+		return "", err
+	}
+
+	env, ok := texEnvironments[name]
+	if !ok {
+		return "", fmt.Errorf("unknown environment %q", name)
+	}
+
+	isCellEnd := func(token texToken) bool {
+		return token.kind == texTokenAlign ||
+			token.kind == texTokenCommand && (token.value == `\` || token.value == "end")
+	}
+
+	var rows strings.Builder
+	var cells []string
+
+	for {
+		cell, err := p.parseRow(isCellEnd)
+		if err != nil {
+			return "", err
+		}
+		cells = append(cells, mathMLElement("mtd", "", mrow(cell)))
+
+		token, ok := p.next()
+		if !ok {
+			return "", fmt.Errorf(`missing \end{%s}`, name)
+		}
+
+		if token.kind == texTokenAlign {
+			continue
+		}
+
+		// Ignore the empty row after a trailing `\\`
+		if !(token.value == "end" && len(cells) == 1 && cells[0] == "<mtd><mrow></mrow></mtd>") {
+			rows.WriteString(mathMLElement("mtr", "", strings.Join(cells, "")))
+		}
+		cells = nil
+
+		if token.value == "end" {
+			end, err := p.parseRawGroup()
+			if err != nil {
+				return "", err
+			}
+			if end != name {
+				return "", fmt.Errorf(`\begin{%s} ended by \end{%s}`, name, end)
+			}
+			break
+		}
+	}
+
+	attrs := ""
+	if env.columnAlign != "" {
+		attrs = fmt.Sprintf(`columnalign=%q`, env.columnAlign)
+	}
+	table := mathMLElement("mtable", attrs, rows.String())
+
+	if env.open == "" && env.close == "" {
+		return table, nil
+	}
+	return "<mrow>" + fence(env.open) + table + fence(env.close) + "</mrow>", nil
+}
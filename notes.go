@@ -0,0 +1,410 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	goldmarkrenderer "github.com/yuin/goldmark/renderer"
+	"gopkg.in/yaml.v2"
+
+	"go.rischmann.fr/website-generator/templates"
+)
+
+const (
+	// notesPerPage is the number of notes in each page of the notes stream.
+	notesPerPage = 10
+	// notesFeedSize is the number of notes in the Atom feed.
+	notesFeedSize = 20
+	// notesFeedPath is the output path of the Atom feed of the notes.
+	notesFeedPath = "notes.xml"
+)
+
+// splitNoteSource splits the source of a notes file into the source of each note.
+//
+// Each note starts with its own front matter, so a note starts at every `---` line followed by a front matter after the first one:
+//
+//	---
+//	date: 2024 March 02
+//	---
+//	First note.
+//	---
+//	date: 2024 March 05
+//	---
+//	Second note.
+//
+// A `---` line inside a fenced code block or not followed by a front matter, like a thematic break, is part of the note.
+func splitNoteSource(data []byte) [][]byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	offsets := make([]int, len(lines)+1)
+	for i, line := range lines {
+		offsets[i+1] = offsets[i] + len(line)
+	}
+
+	var (
+		res   [][]byte
+		start int
+		fence []byte
+	)
+
+	i := 0
+	if end := frontMatterEnd(lines, 0); end > 0 {
+		i = end + 1
+	}
+
+	for ; i < len(lines); i++ {
+		line := lines[i]
+
+		if fence != nil {
+			if isClosingCodeFence(line, fence) {
+				fence = nil
+			}
+			continue
+		}
+		if fence = codeFence(line); fence != nil {
+			continue
+		}
+
+		if end := frontMatterEnd(lines, i); end > 0 {
+			res = append(res, data[start:offsets[i]])
+			start = offsets[i]
+			i = end
+		}
+	}
+
+	return append(res, data[start:])
+}
+
+func isFrontMatterSeparator(line []byte) bool {
+	return string(bytes.TrimRight(line, "\r\n")) == "---"
+}
+
+// frontMatterEnd returns the index of the line closing the front matter opened at lines[i], or -1 if there's no front matter.
+//
+// A front matter is a YAML mapping without blank lines between two `---` lines.
+func frontMatterEnd(lines [][]byte, i int) int {
+	if i >= len(lines) || !isFrontMatterSeparator(lines[i]) {
+		return -1
+	}
+
+	for j := i + 1; j < len(lines); j++ {
+		line := lines[j]
+		if len(bytes.TrimSpace(line)) == 0 {
+			return -1
+		}
+		if !isFrontMatterSeparator(line) {
+			continue
+		}
+		if j == i+1 {
+			return -1
+		}
+
+		var metadata map[string]any
+		if err := yaml.Unmarshal(bytes.Join(lines[i+1:j], nil), &metadata); err != nil || len(metadata) == 0 {
+			return -1
+		}
+		return j
+	}
+
+	return -1
+}
+
+// codeFence returns the fence of the fenced code block opened by line, or nil if the line doesn't open one.
+func codeFence(line []byte) []byte {
+	line = bytes.TrimRight(line, "\r\n")
+	trimmed := bytes.TrimLeft(line, " ")
+	if len(line)-len(trimmed) > 3 || len(trimmed) == 0 || (trimmed[0] != '`' && trimmed[0] != '~') {
+		return nil
+	}
+
+	n := len(trimmed) - len(bytes.TrimLeft(trimmed, string(trimmed[:1])))
+	if n < 3 {
+		return nil
+	}
+	return trimmed[:n]
+}
+
+// isClosingCodeFence returns true if line closes the fenced code block opened with fence.
+func isClosingCodeFence(line []byte, fence []byte) bool {
+	closing := codeFence(line)
+	if closing == nil || closing[0] != fence[0] || len(closing) < len(fence) {
+		return false
+	}
+	rest := bytes.TrimLeft(bytes.TrimSpace(line), string(closing[:1]))
+	return len(rest) == 0
+}
+
+// splitNotes returns the notes of a page with the `note` format.
+//
+// A notes file can contain multiple notes, see [splitNoteSource]. The notes after the first one don't need a `format` value.
+// The slug of a note defaults to the file name, so notes of the same file with the same date must have their own `slug`:
+// it keeps their URL stable when a note is added to the file.
+//
+// Notes don't have a title in their front matter, a title is derived from their date to be used in the page title, the search and the backlinks.
+func splitNotes(parsers *markdownParsers, p page, history *gitHistory) ([]page, error) {
+	sources := splitNoteSource(p.sourceData)
+
+	res := []page{p}
+	if len(sources) > 1 {
+		res = res[:0]
+		for i, source := range sources {
+			note, err := parsePage(parsers, p.sourcePath, source, history)
+			if err != nil {
+				return nil, fmt.Errorf("invalid note #%d, err: %w", i+1, err)
+			}
+
+			switch note.metadata.Format {
+			case "":
+				note.metadata.Format = formatNote
+			case formatNote:
+			default:
+				return nil, fmt.Errorf("invalid `format` value %q in note #%d of page %s, all the notes of a file must have the `note` format", note.metadata.Format, i+1, p.sourcePath)
+			}
+
+			// The git history of the file doesn't say when this note was updated
+			if _, ok := note.metadata.Extra["updated"]; !ok {
+				note.metadata.Updated = time.Time{}
+			}
+
+			res = append(res, note)
+		}
+	}
+
+	dates := make(map[time.Time]int)
+	for i, note := range res {
+		if _, ok := note.metadata.Extra["date"]; !ok {
+			return nil, fmt.Errorf("missing `date` value in note #%d of page %s", i+1, p.sourcePath)
+		}
+		dates[note.metadata.Date]++
+	}
+
+	type noteKey struct {
+		date time.Time
+		slug string
+	}
+	slugs := make(map[noteKey]struct{})
+
+	for i := range res {
+		note := &res[i]
+
+		if dates[note.metadata.Date] > 1 {
+			if note.metadata.Slug == "" {
+				return nil, fmt.Errorf("missing `slug` value in note #%d of page %s, notes of a file with the same date must have a slug", i+1, p.sourcePath)
+			}
+
+			key := noteKey{date: note.metadata.Date, slug: note.metadata.Slug}
+			if _, ok := slugs[key]; ok {
+				return nil, fmt.Errorf("duplicate `slug` value %q in note #%d of page %s", note.metadata.Slug, i+1, p.sourcePath)
+			}
+			slugs[key] = struct{}{}
+		}
+
+		if note.metadata.Title == "" {
+			note.metadata.Title = "Note from " + note.metadata.Date.Format("2006 Jan 02")
+		}
+	}
+
+	return res, nil
+}
+
+// collectNotes returns the notes sorted from the most to the least recent.
+//
+// Notes with the same date are sorted in the reverse order of the files, the last note of a file being the most recent.
+func collectNotes(pages pages) []page {
+	res := pages.getAll(formatNote)
+	slices.Reverse(res)
+	slices.SortStableFunc(res, func(a, b page) int {
+		return b.metadata.Date.Compare(a.metadata.Date)
+	})
+	return res
+}
+
+func templateNote(renderer goldmarkrenderer.Renderer, p page) templates.Note {
+	return templates.Note{
+		URL:  p.url(),
+		Date: p.metadata.Date,
+		Content: markdownHTMLComponent{
+			renderer: renderer,
+			source:   p.sourceData,
+			node:     p.markdownDocument,
+		},
+	}
+}
+
+// notesStreamPath returns the output path of a page of the notes stream, starting at 1.
+func notesStreamPath(n int) string {
+	if n == 1 {
+		return "notes"
+	}
+	return fmt.Sprintf("notes/page/%d", n)
+}
+
+// generateNotes generates the paginated notes stream and the Atom feed of the notes.
+func generateNotes(logger *slog.Logger, generationDate time.Time, renderer goldmarkrenderer.Renderer, buildRootDir string, baseURL string, pages pages) error {
+	ctx := context.Background()
+
+	assets := newAssets(generationDate)
+	assets.add("style.css")
+	assets.add("app.js")
+
+	notes := collectNotes(pages)
+
+	// Generate the stream pages, there's always at least one even without notes
+
+	pageCount := max(1, (len(notes)+notesPerPage-1)/notesPerPage)
+	for n := 1; n <= pageCount; n++ {
+		params := templates.NotesStreamParams{
+			Feed: "/" + notesFeedPath,
+		}
+		for _, note := range notes[(n-1)*notesPerPage : min(n*notesPerPage, len(notes))] {
+			params.Notes = append(params.Notes, templateNote(renderer, note))
+		}
+		if n > 1 {
+			params.Newer = &templates.Link{URL: "/" + notesStreamPath(n-1), Text: "Newer notes"}
+		}
+		if n < pageCount {
+			params.Older = &templates.Link{URL: "/" + notesStreamPath(n+1), Text: "Older notes"}
+		}
+
+		title := "Vincent Rischmann - Notes"
+		if n > 1 {
+			title += fmt.Sprintf(" - Page %d", n)
+		}
+
+		page := templates.Page(
+			templates.HeaderParams{
+				Title:       title,
+				Description: "Short notes by Vincent Rischmann",
+				Feed:        params.Feed,
+			},
+			assets.underlying,
+			templates.NotesStream(params),
+		)
+
+		f, err := createOutputFile(buildRootDir, notesStreamPath(n)+".html")
+		if err != nil {
+			return err
+		}
+
+		logger.Info("generating notes stream",
+			slog.String("output_path", f.Name()),
+			slog.Int("page", n),
+		)
+
+		err = page.Render(ctx, f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("unable to render page to file %q, err: %w", f.Name(), err)
+		}
+	}
+
+	// Generate the feed
+
+	feed, err := buildNotesFeed(renderer, baseURL, notes[:min(notesFeedSize, len(notes))])
+	if err != nil {
+		return err
+	}
+
+	logger.Info("generating notes feed",
+		slog.String("output_path", notesFeedPath),
+		slog.Int("notes", len(feed.Entries)),
+	)
+
+	data, err := xml.MarshalIndent(feed, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to marshal notes feed, err: %w", err)
+	}
+
+	return writeOutputFile(buildRootDir, notesFeedPath, xml.Header+string(data)+"\n")
+}
+
+type atomFeed struct {
+	XMLName xml.Name `xml:"http://www.w3.org/2005/Atom feed"`
+	// Base resolves the root relative URLs of the entries content, like links to other pages
+	Base    string      `xml:"xml:base,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Author  atomAuthor  `xml:"author"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Link      atomLink    `xml:"link"`
+	Content   atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// buildNotesFeed builds the Atom feed of notes. The URLs are made absolute with baseURL, the URLs in the content of the notes
+// are resolved against it with `xml:base`.
+//
+// The feed only depends on the notes: it's updated at the date of the most recent note.
+func buildNotesFeed(renderer goldmarkrenderer.Renderer, baseURL string, notes []page) (atomFeed, error) {
+	res := atomFeed{
+		Base:    baseURL + "/",
+		ID:      baseURL + "/notes",
+		Title:   "Vincent Rischmann - Notes",
+		Updated: time.Unix(0, 0).UTC().Format(time.RFC3339),
+		Author:  atomAuthor{Name: "Vincent Rischmann"},
+		Links: []atomLink{
+			{Href: baseURL + "/" + notesFeedPath, Rel: "self", Type: "application/atom+xml"},
+			{Href: baseURL + "/notes", Rel: "alternate", Type: "text/html"},
+		},
+	}
+
+	var latest time.Time
+	for _, note := range notes {
+		var buf bytes.Buffer
+		if err := renderer.Render(&buf, note.sourceData, note.markdownDocument); err != nil {
+			return atomFeed{}, fmt.Errorf("unable to render note %s, err: %w", note.path, err)
+		}
+
+		updated := note.metadata.Date
+		if note.metadata.Updated.After(updated) {
+			updated = note.metadata.Updated
+		}
+		if updated.After(latest) {
+			latest = updated
+		}
+
+		url := baseURL + note.url()
+		res.Entries = append(res.Entries, atomEntry{
+			ID:        url,
+			Title:     note.metadata.Title,
+			Updated:   updated.UTC().Format(time.RFC3339),
+			Published: note.metadata.Date.UTC().Format(time.RFC3339),
+			Link:      atomLink{Href: url, Rel: "alternate"},
+			Content:   atomContent{Type: "html", Body: buf.String()},
+		})
+	}
+
+	if !latest.IsZero() {
+		res.Updated = latest.UTC().Format(time.RFC3339)
+	}
+
+	return res, nil
+}
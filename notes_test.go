@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/yuin/goldmark"
+	goldmarkmeta "github.com/yuin/goldmark-meta"
+)
+
+func TestSplitNotes(t *testing.T) {
+	parsers := newMarkdownParsers(func(options markdownOptions) goldmark.Markdown {
+		return goldmark.New(goldmark.WithExtensions(goldmarkmeta.Meta))
+	})
+
+	testCases := []struct {
+		name      string
+		source    string
+		wantSlugs []string
+		wantErr   bool
+	}{
+		{
+			name:      "different dates",
+			source:    "---\nformat: note\ndate: 2024 March 02\n---\nFirst.\n---\ndate: 2024 March 05\n---\nSecond.\n",
+			wantSlugs: []string{"", ""},
+		},
+		{
+			name:      "same date with slugs",
+			source:    "---\nformat: note\ndate: 2024 March 02\nslug: first\n---\nFirst.\n---\ndate: 2024 March 02\nslug: second\n---\nSecond.\n",
+			wantSlugs: []string{"first", "second"},
+		},
+		{
+			name:    "same date without slug",
+			source:  "---\nformat: note\ndate: 2024 March 02\nslug: first\n---\nFirst.\n---\ndate: 2024 March 02\n---\nSecond.\n",
+			wantErr: true,
+		},
+		{
+			name:      "separator in a code block",
+			source:    "---\nformat: note\ndate: 2024 March 02\n---\nConfig:\n```yaml\n---\nfoo: bar\n```\n",
+			wantSlugs: []string{""},
+		},
+		{
+			name:      "thematic break",
+			source:    "---\nformat: note\ndate: 2024 March 02\n---\nBefore.\n\n---\n\nAfter.\n",
+			wantSlugs: []string{""},
+		},
+		{
+			name:      "separator in a code block of the second note",
+			source:    "---\nformat: note\ndate: 2024 March 02\n---\nFirst.\n---\ndate: 2024 March 05\n---\n~~~~\n---\ndate: 2024 March 06\n---\n~~~~\n",
+			wantSlugs: []string{"", ""},
+		},
+		{
+			name:    "same date and slug",
+			source:  "---\nformat: note\ndate: 2024 March 02\nslug: first\n---\nFirst.\n---\ndate: 2024 March 02\nslug: first\n---\nSecond.\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := parsePage(parsers, "notes/2024.md", []byte(tc.source), nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			notes, err := splitNotes(parsers, p, nil)
+			if tc.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(notes) != len(tc.wantSlugs) {
+				t.Fatalf("got %d notes, want %d", len(notes), len(tc.wantSlugs))
+			}
+			for i, note := range notes {
+				if note.metadata.Slug != tc.wantSlugs[i] {
+					t.Errorf("got slug %q for note #%d, want %q", note.metadata.Slug, i+1, tc.wantSlugs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildNotesFeedBase(t *testing.T) {
+	pages, cfg := collectTestPages(t, map[string]string{
+		"notes/2024.md": "---\nformat: note\ndate: 2024 March 02\n---\nSee the [talks](/talks).\n",
+	})
+
+	renderer := cfg.newMarkdown(time.Time{}, defaultMarkdownOptions).Renderer()
+	feed, err := buildNotesFeed(renderer, "https://example.com", collectNotes(pages))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := xml.Marshal(feed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `xml:base="https://example.com/"`) {
+		t.Errorf("feed has no xml:base, feed: %s", data)
+	}
+	if len(feed.Entries) != 1 || !strings.Contains(feed.Entries[0].Content.Body, `href="/talks"`) {
+		t.Errorf("unexpected entries %+v", feed.Entries)
+	}
+}
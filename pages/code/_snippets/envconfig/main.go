@@ -0,0 +1,27 @@
+package main
+
+import (
+    "fmt"
+    "log"
+
+    "github.com/vrischmann/envconfig"
+)
+
+type Conf struct {
+    MySQL struct {
+        Host string
+        Port int
+        User string
+        Password string
+    }
+    LogPath string
+}
+
+func main() {
+    var conf Conf
+    if err := envconfig.Init(&conf); err != nil {
+        log.Fatal(err)
+    }
+
+    fmt.Printf("hostname: %s port: %d", conf.MySQL.Host, conf.MySQL.Port)
+}
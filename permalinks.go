@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// defaultPermalinks are the permalink patterns used when none is configured for a format.
+//
+// Pages whose format isn't listed here use [defaultPermalinkPattern].
+var defaultPermalinks = map[string]string{
+	formatBlogEntry: "/blog/:slug",
+	formatNote:      "/notes/:year/:month/:day/:slug",
+}
+
+const defaultPermalinkPattern = "/:path"
+
+// generatedPagePaths are the output paths of pages not backed by a markdown file.
+var generatedPagePaths = map[string]string{
+	"blog":   "the blog index",
+	"code":   "the code index",
+	"notes":  "the notes stream",
+	"resume": "the resume",
+	"search": "the search page",
+	"talks":  "the talks index",
+}
+
+// allGeneratedPagePaths returns [generatedPagePaths] with the output paths of the resume variants declared in resumeConfig.
+//
+// Both the generate and resume-pdf commands collect the pages with it so they reject the same conflicting pages.
+func allGeneratedPagePaths(resumeConfig resumeConfig) map[string]string {
+	res := maps.Clone(generatedPagePaths)
+	maps.Copy(res, resumeConfig.generatedPagePaths())
+	return res
+}
+
+var permalinkTokenRegexp = regexp.MustCompile(`:[a-z]+`)
+
+// expandPermalink computes the output path of a page from a permalink pattern.
+//
+// The supported tokens are:
+// * `:path` the path of the markdown file relative to the pages root directory, without extension
+// * `:dir` the directory of the markdown file relative to the pages root directory
+// * `:slug` the `slug` front matter value, or the markdown file name without extension
+// * `:year`, `:month` and `:day` taken from the `date` front matter value
+//
+// The result is normalized with [normalizeURLPath].
+func expandPermalink(pattern string, sourcePath string, metadata pageMetadata) (string, error) {
+	sourcePath = filepath.ToSlash(sourcePath)
+	sourcePathWithoutExt := strings.TrimSuffix(sourcePath, path.Ext(sourcePath))
+
+	slug := metadata.Slug
+	if slug == "" {
+		slug = path.Base(sourcePathWithoutExt)
+	}
+
+	var err error
+	res := permalinkTokenRegexp.ReplaceAllStringFunc(pattern, func(token string) string {
+		switch token {
+		case ":path":
+			return sourcePathWithoutExt
+		case ":dir":
+			return path.Dir(sourcePath)
+		case ":slug":
+			return slug
+		case ":year", ":month", ":day":
+			if metadata.Date.IsZero() {
+				err = fmt.Errorf("permalink pattern %q uses %s but the page has no `date`", pattern, token)
+				return ""
+			}
+			switch token {
+			case ":year":
+				return metadata.Date.Format("2006")
+			case ":month":
+				return metadata.Date.Format("01")
+			default:
+				return metadata.Date.Format("02")
+			}
+		default:
+			err = fmt.Errorf("invalid permalink pattern %q, unknown token %s", pattern, token)
+			return ""
+		}
+	})
+	if err != nil {
+		return "", err
+	}
+
+	res = normalizeURLPath(res)
+	if res == "" {
+		return "", fmt.Errorf("permalink pattern %q expands to the root path", pattern)
+	}
+
+	return res, nil
+}
+
+// normalizeURLPath turns a URL path into the same form as [page.path]: no leading slash, no trailing slash and no `.html` extension.
+func normalizeURLPath(p string) string {
+	p = strings.TrimSpace(p)
+	p = path.Clean("/" + p)
+	p = strings.TrimSuffix(p, ".html")
+	return strings.TrimPrefix(p, "/")
+}
+
+// checkPagePathCollisions returns an error if two pages, or a page and a generated page, have the same output path.
+//
+// generatedPaths maps the output paths of the generated pages to their description, like [generatedPagePaths].
+func checkPagePathCollisions(pages []page, generatedPaths map[string]string) error {
+	owners := make(map[string]string, len(pages))
+	for _, page := range pages {
+		if owner, ok := generatedPaths[page.path]; ok {
+			return fmt.Errorf("page %s has the output path %q which is already used by %s", page.sourcePath, page.path, owner)
+		}
+		if owner, ok := owners[page.path]; ok {
+			return fmt.Errorf("page %s has the output path %q which is already used by page %s", page.sourcePath, page.path, owner)
+		}
+		owners[page.path] = page.sourcePath
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExpandPermalink(t *testing.T) {
+	date := time.Date(2024, time.March, 2, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name       string
+		pattern    string
+		sourcePath string
+		metadata   pageMetadata
+		want       string
+		wantErr    string
+	}{
+		{name: "path", pattern: "/:path", sourcePath: "code/envconfig.md", want: "code/envconfig"},
+		{name: "dir and slug", pattern: "/:dir/:slug", sourcePath: "code/envconfig.md", metadata: pageMetadata{Slug: "env"}, want: "code/env"},
+		{name: "dir at the root", pattern: "/:dir/:slug", sourcePath: "about.md", want: "about"},
+		{name: "slug defaults to the file name", pattern: "/blog/:slug", sourcePath: "blog/2024/first-post.md", want: "blog/first-post"},
+		{
+			name:       "date",
+			pattern:    "/notes/:year/:month/:day/:slug",
+			sourcePath: "notes/2024.md",
+			metadata:   pageMetadata{Date: date, Slug: "hello"},
+			want:       "notes/2024/03/02/hello",
+		},
+		{name: "trailing slash and extension", pattern: "/docs/:slug.html/", sourcePath: "docs/intro.md", want: "docs/intro"},
+		{name: "missing leading slash", pattern: ":slug", sourcePath: "code/envconfig.md", want: "envconfig"},
+		{name: "date without date", pattern: "/:year/:slug", sourcePath: "about.md", wantErr: "has no `date`"},
+		{name: "unknown token", pattern: "/:title", sourcePath: "about.md", wantErr: "unknown token :title"},
+		{name: "root", pattern: "/", sourcePath: "about.md", wantErr: "expands to the root path"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := expandPermalink(tc.pattern, tc.sourcePath, tc.metadata)
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want an error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tc.want {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeURLPath(t *testing.T) {
+	for input, want := range map[string]string{
+		"/old/path":        "old/path",
+		"old/path/":        "old/path",
+		"/old/path.html":   "old/path",
+		" /old//path ":     "old/path",
+		"/a/../old/./path": "old/path",
+		"/":                "",
+	} {
+		if got := normalizeURLPath(input); got != want {
+			t.Errorf("got %q for %q, want %q", got, input, want)
+		}
+	}
+}
+
+func TestCheckPagePathCollisions(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pages   []page
+		wantErr string
+	}{
+		{
+			name: "no collision",
+			pages: []page{
+				{sourcePath: "about.md", path: "about"},
+				{sourcePath: "code/envconfig.md", path: "code/envconfig"},
+			},
+		},
+		{
+			name: "generated page",
+			pages: []page{
+				{sourcePath: "blog.md", path: "blog"},
+			},
+			wantErr: "already used by the blog index",
+		},
+		{
+			name: "two pages",
+			pages: []page{
+				{sourcePath: "blog/first.md", path: "blog/first"},
+				{sourcePath: "blog/2024/first.md", path: "blog/first"},
+			},
+			wantErr: "already used by page blog/first.md",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			err := checkPagePathCollisions(tc.pages, generatedPagePaths)
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("got error %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"go.rischmann.fr/website-generator/templates"
+)
+
+// projectStatuses are the valid `status` values of a project, in the order they're listed in the code index.
+var projectStatuses = []string{"active", "maintained", "experimental", "archived"}
+
+// project is a page with the `project` format, described by its front matter:
+//
+//	format: project
+//	name: zig-sqlite
+//	tagline: Small wrapper around SQLite's C API
+//	repository: https://github.com/vrischmann/zig-sqlite
+//	language: Zig
+//	status: active
+//	links:
+//	  - text: Documentation
+//	    url: https://example.com
+//
+// Only `name` and `tagline` are required. The page is rendered with the project details above its content and listed in the code index.
+type project struct {
+	Name       string
+	Tagline    string
+	Repository string
+	Language   string
+	Status     string
+	Links      []templates.Link
+
+	page page
+}
+
+func parseProject(metadata pageMetadata) (project, error) {
+	var res project
+
+	err := parseStringFields(metadata, []stringField{
+		{"name", &res.Name, true},
+		{"tagline", &res.Tagline, true},
+		{"repository", &res.Repository, false},
+		{"language", &res.Language, false},
+		{"status", &res.Status, false},
+	})
+	if err != nil {
+		return project{}, err
+	}
+
+	if res.Repository != "" && !strings.HasPrefix(res.Repository, "https://") {
+		return project{}, fmt.Errorf("invalid `repository` value %q, should be a https URL", res.Repository)
+	}
+	if res.Status != "" && !slices.Contains(projectStatuses, res.Status) {
+		return project{}, fmt.Errorf("invalid `status` value %q, should be one of \"active\", \"maintained\", \"experimental\" or \"archived\"", res.Status)
+	}
+
+	if tmp, ok := metadata.Extra["links"]; ok {
+		values, ok := tmp.([]any)
+		if !ok {
+			return project{}, fmt.Errorf("invalid `links` value %v, should be a list", tmp)
+		}
+
+		for _, v := range values {
+			fields, ok := v.(map[any]any)
+			if !ok {
+				return project{}, fmt.Errorf("invalid `links` element %v, should be a map with the keys \"text\" and \"url\"", v)
+			}
+
+			text, _ := fields["text"].(string)
+			url, _ := fields["url"].(string)
+			if text == "" || url == "" || len(fields) != 2 {
+				return project{}, fmt.Errorf("invalid `links` element %v, should be a map with the keys \"text\" and \"url\"", v)
+			}
+
+			res.Links = append(res.Links, templates.Link{URL: url, Text: text})
+		}
+	}
+
+	return res, nil
+}
+
+func (p project) templateProject() templates.Project {
+	return templates.Project{
+		URL:        p.page.url(),
+		Name:       p.Name,
+		Tagline:    p.Tagline,
+		Repository: p.Repository,
+		Language:   p.Language,
+		Status:     p.Status,
+		Links:      p.Links,
+	}
+}
+
+// collectProjects returns the projects sorted by status then by name.
+func collectProjects(pages pages) ([]project, error) {
+	var res []project
+	for _, page := range pages.getAll(formatProject) {
+		project, err := parseProject(page.metadata)
+		if err != nil {
+			return nil, fmt.Errorf("invalid project %s, err: %w", page.sourcePath, err)
+		}
+		project.page = page
+
+		res = append(res, project)
+	}
+
+	statusOrder := func(status string) int {
+		if status == "" {
+			// Projects without a status come after the others
+			return len(projectStatuses)
+		}
+		return slices.Index(projectStatuses, status)
+	}
+
+	slices.SortFunc(res, func(a, b project) int {
+		return cmp.Or(
+			cmp.Compare(statusOrder(a.Status), statusOrder(b.Status)),
+			strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name)),
+		)
+	})
+
+	return res, nil
+}
+
+func generateCodeIndex(logger *slog.Logger, generationDate time.Time, buildRootDir string, pages pages) error {
+	ctx := context.Background()
+
+	assets := newAssets(generationDate)
+	assets.add("style.css")
+	assets.add("app.js")
+
+	// Generate the index page
+
+	projects, err := collectProjects(pages)
+	if err != nil {
+		return err
+	}
+
+	items := make([]templates.Project, 0, len(projects))
+	for _, project := range projects {
+		items = append(items, project.templateProject())
+	}
+
+	page := templates.Page(
+		templates.HeaderParams{
+			Title:       "Vincent Rischmann - Code",
+			Description: "Index of personal, open source projects built by Vincent Rischmann",
+		},
+		assets.underlying,
+		templates.CodeIndex(items),
+	)
+
+	// Rendering page
+
+	f, err := createOutputFile(buildRootDir, "code.html")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	logger.Info("generating code index",
+		slog.String("output_path", f.Name()),
+		slog.Int("projects", len(items)),
+	)
+
+	if err := page.Render(ctx, f); err != nil {
+		return fmt.Errorf("unable to render page to file %q, err: %w", f.Name(), err)
+	}
+
+	return nil
+}
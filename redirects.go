@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"maps"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v2"
+
+	"go.rischmann.fr/website-generator/templates"
+)
+
+// redirect maps an old path to the URL it should now redirect to.
+type redirect struct {
+	From string `yaml:"from"`
+	To   string `yaml:"to"`
+
+	source string // where the redirect was declared, used in error messages
+}
+
+// readRedirectsFile reads the global redirects file.
+//
+// The file is a YAML list of `from`/`to` pairs. A missing file is not an error.
+func readRedirectsFile(filename string) ([]redirect, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("unable to read redirects file %q, err: %w", filename, err)
+	}
+
+	var res []redirect
+	if err := yaml.UnmarshalStrict(data, &res); err != nil {
+		return nil, fmt.Errorf("unable to parse redirects file %q, err: %w", filename, err)
+	}
+
+	for i := range res {
+		if res[i].From == "" || res[i].To == "" {
+			return nil, fmt.Errorf("invalid redirect #%d in %q, both `from` and `to` are required", i, filename)
+		}
+		res[i].source = filename
+	}
+
+	return res, nil
+}
+
+// collectRedirects builds the list of redirects from the pages aliases and the global redirects.
+//
+// It returns an error if a redirect would shadow a real page or if the same path is redirected twice.
+func collectRedirects(pages pages, generatedPaths map[string]string, globalRedirects []redirect) ([]redirect, error) {
+	taken := make(map[string]string, len(pages)+len(generatedPaths))
+	for _, page := range pages {
+		taken[page.path] = "page " + page.sourcePath
+	}
+	maps.Copy(taken, generatedPaths)
+
+	var res []redirect
+	for _, page := range pages {
+		for _, alias := range page.metadata.Aliases {
+			res = append(res, redirect{
+				From:   alias,
+				To:     page.url(),
+				source: "page " + page.sourcePath,
+			})
+		}
+	}
+	res = append(res, globalRedirects...)
+
+	redirected := make(map[string]string, len(res))
+	for i, r := range res {
+		from := normalizeURLPath(r.From)
+		if from == "" {
+			return nil, fmt.Errorf("invalid redirect %q declared by %s, cannot redirect the root", r.From, r.source)
+		}
+
+		if owner, ok := taken[from]; ok {
+			return nil, fmt.Errorf("redirect %q declared by %s conflicts with %s", r.From, r.source, owner)
+		}
+		if owner, ok := redirected[from]; ok {
+			return nil, fmt.Errorf("redirect %q declared by %s is already declared by %s", r.From, r.source, owner)
+		}
+		redirected[from] = r.source
+
+		// The target is written as is in the redirects files, which separate the fields with whitespace
+		if strings.ContainsFunc(r.To, func(r rune) bool { return unicode.IsSpace(r) || r == '"' }) {
+			return nil, fmt.Errorf("invalid redirect target %q declared by %s, it can't contain whitespace or quotes", r.To, r.source)
+		}
+
+		res[i].From = from
+	}
+
+	slices.SortFunc(res, func(a, b redirect) int {
+		return strings.Compare(a.From, b.From)
+	})
+
+	return res, nil
+}
+
+// generateRedirects writes the redirects in three forms:
+// * a HTML stub using a meta refresh for every old path, which works with any static file server
+// * a `redirects.caddy` snippet with `redir` directives, meant to be imported in the Caddyfile
+// * a `_redirects` file in the format understood by Netlify-like hosts
+func generateRedirects(logger *slog.Logger, buildRootDir string, redirects []redirect) error {
+	ctx := context.Background()
+
+	var (
+		caddyBuf     strings.Builder
+		redirectsBuf strings.Builder
+	)
+
+	for _, r := range redirects {
+		if err := generateRedirectStub(ctx, logger, buildRootDir, r); err != nil {
+			return err
+		}
+
+		// The old path can contain whitespace, like the name of a file, it's escaped to be a single field
+		from := (&url.URL{Path: "/" + r.From}).EscapedPath()
+
+		fmt.Fprintf(&caddyBuf, "redir %s %s permanent\n", from, r.To)
+		fmt.Fprintf(&redirectsBuf, "%s %s 301\n", from, r.To)
+	}
+
+	for name, content := range map[string]string{
+		"redirects.caddy": caddyBuf.String(),
+		"_redirects":      redirectsBuf.String(),
+	} {
+		if err := writeOutputFile(buildRootDir, name, content); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func generateRedirectStub(ctx context.Context, logger *slog.Logger, buildRootDir string, r redirect) error {
+	f, err := createOutputFile(buildRootDir, r.From+".html")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	logger.Info("generating redirect",
+		slog.String("from", r.From),
+		slog.String("to", r.To),
+		slog.String("output_path", f.Name()),
+	)
+
+	if err := templates.Redirect(r.To).Render(ctx, f); err != nil {
+		return fmt.Errorf("unable to render redirect to file %q, err: %w", f.Name(), err)
+	}
+
+	return nil
+}
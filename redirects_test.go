@@ -0,0 +1,53 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateRedirects(t *testing.T) {
+	redirects, err := collectRedirects(nil, nil, []redirect{
+		{From: "/files/MeetUp Golang Lyon 06_2023.pdf", To: "/talks/golang-lyon-2023-06", source: "redirects.yaml"},
+		{From: "/old.html", To: "https://example.com/new?a=b", source: "redirects.yaml"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	if err := generateRedirects(slog.New(slog.DiscardHandler), dir, redirects); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]string{
+		"redirects.caddy": "redir /files/MeetUp%20Golang%20Lyon%2006_2023.pdf /talks/golang-lyon-2023-06 permanent\n" +
+			"redir /old https://example.com/new?a=b permanent\n",
+		"_redirects": "/files/MeetUp%20Golang%20Lyon%2006_2023.pdf /talks/golang-lyon-2023-06 301\n" +
+			"/old https://example.com/new?a=b 301\n",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(data) != want {
+			t.Errorf("got %s:\n%s\nwant:\n%s", name, data, want)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "files", "MeetUp Golang Lyon 06_2023.pdf.html")); err != nil {
+		t.Errorf("missing redirect stub, err: %v", err)
+	}
+}
+
+func TestCollectRedirectsInvalidTarget(t *testing.T) {
+	for _, to := range []string{"/new path", `/new"path`, "/new\tpath"} {
+		_, err := collectRedirects(nil, nil, []redirect{
+			{From: "/old", To: to, source: "redirects.yaml"},
+		})
+		if err == nil {
+			t.Errorf("expected an error for the target %q", to)
+		}
+	}
+}
@@ -0,0 +1,663 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	goldmarkast "github.com/yuin/goldmark/ast"
+	goldmarkrenderer "github.com/yuin/goldmark/renderer"
+	"gopkg.in/yaml.v2"
+
+	"go.rischmann.fr/website-generator/templates"
+)
+
+// resumeBasics is the identity shown at the top of the resume, parsed from the front matter of the `header`,
+// `summary` and `interests` resume parts:
+//
+//	id: header
+//	name: Vincent Rischmann
+//	label: Staff engineer
+//	email: vincent@rischmann.fr
+//	website: https://rischmann.fr
+//	pdf: /files/resume.pdf
+//	profiles:
+//	  - network: GitHub
+//	    username: vrischmann
+//	    url: https://github.com/vrischmann
+//
+//	id: summary
+//	summary: I am a Staff engineer.
+//
+//	id: interests
+//	interests:
+//	  - Movies, TV shows.
+//
+// These parts have no content, only front matter.
+type resumeBasics struct {
+	Name      string
+	Label     string
+	Email     string
+	Website   string
+	PDF       string
+	Profiles  []resumeProfile
+	Summary   string
+	Interests []string
+}
+
+type resumeProfile struct {
+	Network  string
+	Username string
+	URL      string
+}
+
+func parseResumeHeader(metadata pageMetadata, basics *resumeBasics) error {
+	err := parseStringFields(metadata, []stringField{
+		{"name", &basics.Name, true},
+		{"label", &basics.Label, true},
+		{"email", &basics.Email, true},
+		{"website", &basics.Website, true},
+		{"pdf", &basics.PDF, false},
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp, ok := metadata.Extra["profiles"]
+	if !ok {
+		return nil
+	}
+
+	values, ok := tmp.([]any)
+	if !ok {
+		return fmt.Errorf("invalid `profiles` value %v, should be a list", tmp)
+	}
+	for _, v := range values {
+		fields, ok := v.(map[any]any)
+		if !ok {
+			return fmt.Errorf("invalid `profiles` element %v, should be a map with the keys \"network\", \"username\" and \"url\"", v)
+		}
+
+		var profile resumeProfile
+		for key, value := range fields {
+			str, ok := value.(string)
+			if !ok || str == "" {
+				return fmt.Errorf("invalid `profiles.%v` value %v, should be a non empty string", key, value)
+			}
+
+			switch key {
+			case "network":
+				profile.Network = str
+			case "username":
+				profile.Username = str
+			case "url":
+				profile.URL = str
+			default:
+				return fmt.Errorf("invalid `profiles` key %v, should be one of \"network\", \"username\" or \"url\"", key)
+			}
+		}
+		if profile.Network == "" || profile.URL == "" {
+			return fmt.Errorf("invalid `profiles` element %v, both `network` and `url` are required", v)
+		}
+
+		basics.Profiles = append(basics.Profiles, profile)
+	}
+
+	return nil
+}
+
+// resumeConfig is the content of the resume file declaring the resume variants:
+//
+//	default: staff
+//	variants:
+//	  - name: staff
+//	    title: Staff engineer
+//	    summary: I am a Staff engineer with 10+ years of experience.
+//	    tags: [staff]
+//
+// Each variant is generated at `/resume/<name>`, the default variant is also generated at `/resume`.
+// Without a default variant, `/resume` contains every resume part.
+type resumeConfig struct {
+	Default  string          `yaml:"default"`
+	Variants []resumeVariant `yaml:"variants"`
+}
+
+// resumeVariant selects the resume parts included in a variant of the resume.
+//
+// A part is included if it has no `tags` or if it has one of the variant tags, and if its `id` is one of the variant ids.
+// All ids are included by default, the header is always included.
+type resumeVariant struct {
+	Name string `yaml:"name"`
+	// Title replaces the label shown below the name if not empty.
+	Title string `yaml:"title"`
+	// Summary replaces the default summary if not empty.
+	Summary string   `yaml:"summary"`
+	Tags    []string `yaml:"tags"`
+	IDs     []string `yaml:"ids"`
+
+	// all is true for the resume including every part, see [resumeConfig.defaultVariant].
+	all bool
+}
+
+var (
+	resumeVariantNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	resumePartIDs           = []string{"header", "summary", "skills", "work_experience", "side_projects", "interests"}
+	resumePartIDsList       = `"header", "summary", "skills", "work_experience", "side_projects" or "interests"`
+)
+
+// readResumeFile reads the resume variants file.
+//
+// A missing file is not an error, there's only the default resume in that case.
+func readResumeFile(filename string) (resumeConfig, error) {
+	data, err := os.ReadFile(filename)
+	if errors.Is(err, fs.ErrNotExist) {
+		return resumeConfig{}, nil
+	} else if err != nil {
+		return resumeConfig{}, fmt.Errorf("unable to read resume file %q, err: %w", filename, err)
+	}
+
+	var res resumeConfig
+	if err := yaml.UnmarshalStrict(data, &res); err != nil {
+		return resumeConfig{}, fmt.Errorf("unable to parse resume file %q, err: %w", filename, err)
+	}
+
+	names := make(map[string]struct{}, len(res.Variants))
+	for i, variant := range res.Variants {
+		if !resumeVariantNameRegexp.MatchString(variant.Name) {
+			return resumeConfig{}, fmt.Errorf("invalid resume variant #%d in %q, invalid `name` value %q, should only contain lowercase letters, digits and dashes", i, filename, variant.Name)
+		}
+		if _, ok := names[variant.Name]; ok {
+			return resumeConfig{}, fmt.Errorf("invalid resume variant #%d in %q, the name %q is already used", i, filename, variant.Name)
+		}
+		names[variant.Name] = struct{}{}
+
+		for _, id := range variant.IDs {
+			if !slices.Contains(resumePartIDs, id) {
+				return resumeConfig{}, fmt.Errorf("invalid resume variant %q in %q, invalid `ids` element %q, should be one of %s", variant.Name, filename, id, resumePartIDsList)
+			}
+		}
+	}
+
+	if res.Default != "" && res.defaultVariant() == nil {
+		return resumeConfig{}, fmt.Errorf("invalid `default` value %q in %q, should be the name of a variant", res.Default, filename)
+	}
+
+	return res, nil
+}
+
+// defaultVariant returns the variant generated at `/resume`, which includes every part if there's no default variant.
+func (c resumeConfig) defaultVariant() *resumeVariant {
+	if c.Default == "" {
+		return &resumeVariant{all: true}
+	}
+	return c.variant(c.Default)
+}
+
+func (c resumeConfig) variant(name string) *resumeVariant {
+	for i := range c.Variants {
+		if c.Variants[i].Name == name {
+			return &c.Variants[i]
+		}
+	}
+	return nil
+}
+
+// generatedPagePaths returns the output paths of the resume variants, in the same form as [generatedPagePaths].
+func (c resumeConfig) generatedPagePaths() map[string]string {
+	res := make(map[string]string, len(c.Variants))
+	for _, variant := range c.Variants {
+		res["resume/"+variant.Name] = fmt.Sprintf("the resume variant %q", variant.Name)
+	}
+	return res
+}
+
+func (v *resumeVariant) includes(id string, tags []string) bool {
+	if v.all {
+		return true
+	}
+	if len(v.IDs) > 0 && id != "header" && !slices.Contains(v.IDs, id) {
+		return false
+	}
+	if len(tags) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(tags, func(tag string) bool {
+		return slices.Contains(v.Tags, tag)
+	})
+}
+
+// resumeDateLayout is the layout of the `start` and `end` dates of a work experience.
+const resumeDateLayout = "2006/01"
+
+// resumeExperience is a work experience parsed from the front matter of a `resume_part` page with `id: work_experience`:
+//
+//	company: Batch.com
+//	role: Staff engineer
+//	location: Paris, France
+//	start: 2023/02
+//	end: 2024/06
+//	highlights:
+//	  - Scaled the push notification services to 400k push/s
+//	tech: [Go, Kafka]
+//
+// The `end` date is optional, a missing end date means the position is current.
+// The body of the page is the description of the experience.
+type resumeExperience struct {
+	Company    string
+	Role       string
+	Location   string
+	Start      time.Time
+	End        time.Time
+	Highlights []string
+	Tech       []string
+
+	page page
+}
+
+func parseResumeExperience(metadata pageMetadata) (resumeExperience, error) {
+	var res resumeExperience
+
+	err := parseStringFields(metadata, []stringField{
+		{"company", &res.Company, true},
+		{"role", &res.Role, true},
+		{"location", &res.Location, false},
+	})
+	if err != nil {
+		return resumeExperience{}, err
+	}
+
+	for _, field := range []struct {
+		key      string
+		ptr      *time.Time
+		required bool
+	}{
+		{"start", &res.Start, true},
+		{"end", &res.End, false},
+	} {
+		tmp, ok := metadata.Extra[field.key]
+		if !ok {
+			if field.required {
+				return resumeExperience{}, fmt.Errorf("missing `%s` value", field.key)
+			}
+			continue
+		}
+
+		dateStr, ok := tmp.(string)
+		if !ok {
+			return resumeExperience{}, fmt.Errorf("invalid `%s` value %v, should be a string", field.key, tmp)
+		}
+		date, err := time.Parse(resumeDateLayout, dateStr)
+		if err != nil {
+			return resumeExperience{}, fmt.Errorf("invalid `%s` value %q, should be a date in the `2006/01` format", field.key, dateStr)
+		}
+		*field.ptr = date
+	}
+
+	if !res.End.IsZero() && res.End.Before(res.Start) {
+		return resumeExperience{}, fmt.Errorf("invalid `end` value %q, should not be before the `start` value %q",
+			res.End.Format(resumeDateLayout), res.Start.Format(resumeDateLayout))
+	}
+
+	if res.Highlights, err = parseStringList(metadata, "highlights"); err != nil {
+		return resumeExperience{}, err
+	}
+	if res.Tech, err = parseStringList(metadata, "tech"); err != nil {
+		return resumeExperience{}, err
+	}
+
+	return res, nil
+}
+
+// compareResumeExperiences sorts the experiences from the most recent to the oldest: current positions first,
+// then by descending end date and start date.
+func compareResumeExperiences(a, b resumeExperience) int {
+	end := func(e resumeExperience) time.Time {
+		if e.End.IsZero() {
+			return time.Date(9999, time.December, 1, 0, 0, 0, 0, time.UTC)
+		}
+		return e.End
+	}
+
+	return cmp.Or(
+		end(b).Compare(end(a)),
+		b.Start.Compare(a.Start),
+	)
+}
+
+// resumeSkill is an item of the skills list, like `* **Experienced** Go, Java`.
+type resumeSkill struct {
+	Level    string
+	Keywords []string
+}
+
+func parseResumeSkills(part page) ([]resumeSkill, error) {
+	var res []resumeSkill
+
+	for _, item := range collectListItems(part.markdownDocument) {
+		block := item.FirstChild()
+
+		level, ok := block.FirstChild().(*goldmarkast.Emphasis)
+		if !ok || level.Level != 2 {
+			return nil, fmt.Errorf("line %d: a skill should start with its level in bold, like `**Experienced** Go, Java`", blockLineNumber(block, part.sourceData))
+		}
+
+		skill := resumeSkill{
+			Level: extractText(level, part.sourceData),
+		}
+		for _, keyword := range strings.Split(extractTextAfter(level, part.sourceData), ",") {
+			if keyword = strings.TrimSpace(keyword); keyword != "" {
+				skill.Keywords = append(skill.Keywords, keyword)
+			}
+		}
+
+		res = append(res, skill)
+	}
+
+	return res, nil
+}
+
+// resumeProject is an item of the side projects list, like `* [envconfig](https://rischmann.fr/code/envconfig), a library`.
+type resumeProject struct {
+	Name        string
+	URL         string
+	Description string
+}
+
+func parseResumeProjects(part page) ([]resumeProject, error) {
+	var res []resumeProject
+
+	for _, item := range collectListItems(part.markdownDocument) {
+		block := item.FirstChild()
+
+		link, ok := block.FirstChild().(*goldmarkast.Link)
+		if !ok {
+			return nil, fmt.Errorf("line %d: a side project should start with a link to the project, like `[envconfig](https://rischmann.fr/code/envconfig), a library`", blockLineNumber(block, part.sourceData))
+		}
+
+		description := strings.TrimSpace(extractTextAfter(link, part.sourceData))
+		description = strings.TrimSpace(strings.TrimPrefix(description, ","))
+
+		res = append(res, resumeProject{
+			Name:        extractText(link, part.sourceData),
+			URL:         string(link.Destination),
+			Description: description,
+		})
+	}
+
+	return res, nil
+}
+
+func collectListItems(document goldmarkast.Node) []*goldmarkast.ListItem {
+	var res []*goldmarkast.ListItem
+	goldmarkast.Walk(document, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+		if item, ok := n.(*goldmarkast.ListItem); ok && entering {
+			res = append(res, item)
+			return goldmarkast.WalkSkipChildren, nil
+		}
+		return goldmarkast.WalkContinue, nil
+	})
+	return res
+}
+
+// extractTextAfter returns the text content of the siblings following a node.
+func extractTextAfter(node goldmarkast.Node, source []byte) string {
+	var buf strings.Builder
+	for n := node.NextSibling(); n != nil; n = n.NextSibling() {
+		buf.WriteString(extractText(n, source))
+	}
+	return buf.String()
+}
+
+func blockLineNumber(block goldmarkast.Node, source []byte) int {
+	if block == nil || block.Lines().Len() == 0 {
+		return 0
+	}
+	return lineNumber(source, block.Lines().At(0).Start)
+}
+
+// resume contains the parts of the resume, validated and sorted.
+type resume struct {
+	basics      resumeBasics
+	experiences []resumeExperience
+
+	skillsPart *page
+	skills     []resumeSkill
+
+	sideProjectsPart *page
+	sideProjects     []resumeProject
+}
+
+// collectResume collects the resume parts included in a variant.
+//
+// Only work experiences can have multiple parts, for the other ids it's an error if a variant includes more than one part.
+func collectResume(pages pages, variant *resumeVariant) (resume, error) {
+	var res resume
+
+	included := make(map[string]string)
+	for _, part := range pages.getAll(formatResumePart) {
+		id, ok := part.metadata.Extra["id"].(string)
+		if !ok || !slices.Contains(resumePartIDs, id) {
+			return resume{}, fmt.Errorf("invalid `id` value %v in resume part %s, should be one of %s", part.metadata.Extra["id"], part.sourcePath, resumePartIDsList)
+		}
+
+		tags, err := parseStringList(part.metadata, "tags")
+		if err != nil {
+			return resume{}, fmt.Errorf("invalid resume part %s, err: %w", part.sourcePath, err)
+		}
+		if !variant.includes(id, tags) {
+			continue
+		}
+
+		if id != "work_experience" {
+			if other, ok := included[id]; ok {
+				return resume{}, fmt.Errorf("resume part %s has the `id` %q which is already used by resume part %s", part.sourcePath, id, other)
+			}
+			included[id] = part.sourcePath
+		}
+
+		switch id {
+		case "header", "summary", "interests":
+			if part.markdownDocument.HasChildren() {
+				return resume{}, fmt.Errorf("invalid resume part %s, a `%s` part should only have front matter", part.sourcePath, id)
+			}
+		}
+
+		switch id {
+		case "header":
+			if err := parseResumeHeader(part.metadata, &res.basics); err != nil {
+				return resume{}, fmt.Errorf("invalid header %s, err: %w", part.sourcePath, err)
+			}
+
+		case "summary":
+			err := parseStringFields(part.metadata, []stringField{
+				{"summary", &res.basics.Summary, true},
+			})
+			if err != nil {
+				return resume{}, fmt.Errorf("invalid summary %s, err: %w", part.sourcePath, err)
+			}
+
+		case "interests":
+			res.basics.Interests, err = parseStringList(part.metadata, "interests")
+			if err != nil {
+				return resume{}, fmt.Errorf("invalid interests %s, err: %w", part.sourcePath, err)
+			}
+
+		case "skills":
+			skills, err := parseResumeSkills(part)
+			if err != nil {
+				return resume{}, fmt.Errorf("invalid skills %s, err: %w", part.sourcePath, err)
+			}
+
+			res.skillsPart = &part
+			res.skills = skills
+
+		case "work_experience":
+			experience, err := parseResumeExperience(part.metadata)
+			if err != nil {
+				return resume{}, fmt.Errorf("invalid work experience %s, err: %w", part.sourcePath, err)
+			}
+			experience.page = part
+
+			res.experiences = append(res.experiences, experience)
+
+		case "side_projects":
+			sideProjects, err := parseResumeProjects(part)
+			if err != nil {
+				return resume{}, fmt.Errorf("invalid side projects %s, err: %w", part.sourcePath, err)
+			}
+
+			res.sideProjectsPart = &part
+			res.sideProjects = sideProjects
+		}
+	}
+
+	if _, ok := included["header"]; !ok {
+		return resume{}, errors.New("missing resume part with `id: header`")
+	}
+
+	res.basics.Label = cmp.Or(variant.Title, res.basics.Label)
+	res.basics.Summary = cmp.Or(variant.Summary, res.basics.Summary)
+
+	slices.SortStableFunc(res.experiences, compareResumeExperiences)
+
+	return res, nil
+}
+
+func generateResume(logger *slog.Logger, generationDate time.Time, render goldmarkrenderer.Renderer, buildRootDir string, pages pages, config resumeConfig) error {
+	// Generate the default resume and its exports
+
+	data, err := collectResume(pages, config.defaultVariant())
+	if err != nil {
+		return err
+	}
+
+	if err := renderResume(logger, generationDate, render, buildRootDir, "resume.html", data); err != nil {
+		return err
+	}
+
+	jsonResume, err := exportJSONResume(data)
+	if err != nil {
+		return err
+	}
+
+	for _, export := range []struct {
+		path    string
+		content []byte
+	}{
+		{"resume.json", jsonResume},
+		{"resume.txt", exportTextResume(data)},
+		{"resume.md", exportMarkdownResume(data)},
+	} {
+		logger.Info("exporting resume",
+			slog.String("output_path", filepath.Join(buildRootDir, export.path)),
+		)
+
+		if err := writeOutputFile(buildRootDir, export.path, string(export.content)); err != nil {
+			return err
+		}
+	}
+
+	// Generate the variants
+
+	for _, variant := range config.Variants {
+		data, err := collectResume(pages, &variant)
+		if err != nil {
+			return err
+		}
+
+		if err := renderResume(logger, generationDate, render, buildRootDir, "resume/"+variant.Name+".html", data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func renderResume(logger *slog.Logger, generationDate time.Time, render goldmarkrenderer.Renderer, buildRootDir string, outputPath string, data resume) error {
+	ctx := context.Background()
+
+	// The resume doesn't use app.js: it has no navigation and must look the same regardless of the theme
+	assets := newAssets(generationDate)
+	assets.add("style.css")
+
+	// Build the resume components
+
+	partComponent := func(part *page) templ.Component {
+		if part == nil {
+			return templ.NopComponent
+		}
+		return markdownHTMLComponent{
+			renderer: render,
+			source:   part.sourceData,
+			node:     part.markdownDocument,
+		}
+	}
+
+	experiences := make([]templates.ResumeExperience, 0, len(data.experiences))
+	for _, experience := range data.experiences {
+		experiences = append(experiences, templates.ResumeExperience{
+			Company:     experience.Company,
+			Role:        experience.Role,
+			Location:    experience.Location,
+			Start:       experience.Start,
+			End:         experience.End,
+			Highlights:  experience.Highlights,
+			Tech:        experience.Tech,
+			Description: partComponent(&experience.page),
+		})
+	}
+
+	basics := templates.ResumeBasics{
+		Name:      data.basics.Name,
+		Label:     data.basics.Label,
+		Email:     data.basics.Email,
+		Website:   data.basics.Website,
+		Summary:   data.basics.Summary,
+		PDF:       data.basics.PDF,
+		Interests: data.basics.Interests,
+	}
+	for _, profile := range data.basics.Profiles {
+		basics.Profiles = append(basics.Profiles, templates.ResumeProfile{
+			Network: profile.Network,
+			URL:     profile.URL,
+		})
+	}
+
+	resume := templates.Resume(basics, partComponent(data.skillsPart), experiences, partComponent(data.sideProjectsPart))
+	page := templates.PageWithLayout(
+		templates.HeaderParams{
+			Title:       data.basics.Name + " - Resume",
+			Description: "",
+		},
+		assets.underlying,
+		resume,
+	)
+
+	// Rendering page
+
+	f, err := createOutputFile(buildRootDir, outputPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	logger.Info("generating resume",
+		slog.String("output_path", f.Name()),
+	)
+
+	if err := page.Render(ctx, f); err != nil {
+		return fmt.Errorf("unable to render page to file %q, err: %w", f.Name(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	goldmarkast "github.com/yuin/goldmark/ast"
+)
+
+// jsonResumeSchema is the JSON Resume schema implemented by [jsonResume], see https://jsonresume.org/schema.
+const jsonResumeSchema = "https://raw.githubusercontent.com/jsonresume/resume-schema/v1.0.0/schema.json"
+
+// jsonResumeDateLayout is the layout of the dates in the JSON Resume schema.
+const jsonResumeDateLayout = "2006-01"
+
+type jsonResume struct {
+	Schema    string               `json:"$schema"`
+	Basics    jsonResumeBasics     `json:"basics"`
+	Work      []jsonResumeWork     `json:"work"`
+	Skills    []jsonResumeSkill    `json:"skills"`
+	Projects  []jsonResumeProject  `json:"projects"`
+	Interests []jsonResumeInterest `json:"interests"`
+}
+
+type jsonResumeBasics struct {
+	Name     string              `json:"name"`
+	Label    string              `json:"label"`
+	Email    string              `json:"email"`
+	URL      string              `json:"url"`
+	Summary  string              `json:"summary"`
+	Profiles []jsonResumeProfile `json:"profiles"`
+}
+
+type jsonResumeProfile struct {
+	Network  string `json:"network"`
+	Username string `json:"username"`
+	URL      string `json:"url"`
+}
+
+type jsonResumeWork struct {
+	Name       string   `json:"name"`
+	Position   string   `json:"position"`
+	Location   string   `json:"location,omitempty"`
+	StartDate  string   `json:"startDate"`
+	EndDate    string   `json:"endDate,omitempty"`
+	Summary    string   `json:"summary"`
+	Highlights []string `json:"highlights,omitempty"`
+	// Keywords is not part of the schema, which allows additional properties.
+	Keywords []string `json:"keywords,omitempty"`
+}
+
+type jsonResumeSkill struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+}
+
+type jsonResumeProject struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	URL         string `json:"url"`
+}
+
+type jsonResumeInterest struct {
+	Name string `json:"name"`
+}
+
+// exportJSONResume returns the resume in the JSON Resume format.
+func exportJSONResume(data resume) ([]byte, error) {
+	res := jsonResume{
+		Schema: jsonResumeSchema,
+		Basics: jsonResumeBasics{
+			Name:    data.basics.Name,
+			Label:   data.basics.Label,
+			Email:   data.basics.Email,
+			URL:     data.basics.Website,
+			Summary: data.basics.Summary,
+		},
+	}
+
+	for _, profile := range data.basics.Profiles {
+		res.Basics.Profiles = append(res.Basics.Profiles, jsonResumeProfile(profile))
+	}
+
+	for _, experience := range data.experiences {
+		work := jsonResumeWork{
+			Name:       experience.Company,
+			Position:   experience.Role,
+			Location:   experience.Location,
+			StartDate:  experience.Start.Format(jsonResumeDateLayout),
+			Summary:    strings.Join(experience.descriptionParagraphs(), "\n\n"),
+			Highlights: experience.Highlights,
+			Keywords:   experience.Tech,
+		}
+		if !experience.End.IsZero() {
+			work.EndDate = experience.End.Format(jsonResumeDateLayout)
+		}
+
+		res.Work = append(res.Work, work)
+	}
+
+	// A skill of the resume is a level with its keywords, each keyword is a skill with this level in the schema
+	for _, skill := range data.skills {
+		for _, keyword := range skill.Keywords {
+			res.Skills = append(res.Skills, jsonResumeSkill{
+				Name:  keyword,
+				Level: skill.Level,
+			})
+		}
+	}
+
+	for _, project := range data.sideProjects {
+		res.Projects = append(res.Projects, jsonResumeProject{
+			Name:        project.Name,
+			Description: project.Description,
+			URL:         project.URL,
+		})
+	}
+
+	for _, interest := range data.basics.Interests {
+		res.Interests = append(res.Interests, jsonResumeInterest{
+			Name: strings.TrimSuffix(interest, "."),
+		})
+	}
+
+	content, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("unable to marshal JSON resume, err: %w", err)
+	}
+
+	return append(content, '\n'), nil
+}
+
+// resumeTextWidth is the maximum line width of the plain text resume.
+const resumeTextWidth = 80
+
+// exportTextResume returns the resume as plain text.
+func exportTextResume(data resume) []byte {
+	var buf bytes.Buffer
+
+	heading := func(title string) {
+		fmt.Fprintf(&buf, "\n%s\n%s\n\n", strings.ToUpper(title), strings.Repeat("=", len(title)))
+	}
+
+	fmt.Fprintf(&buf, "%s\n%s\n\n", data.basics.Name, data.basics.Label)
+	fmt.Fprintf(&buf, "Email: %s\n", data.basics.Email)
+	fmt.Fprintf(&buf, "Website: %s\n", data.basics.Website)
+	for _, profile := range data.basics.Profiles {
+		fmt.Fprintf(&buf, "%s: %s\n", profile.Network, profile.URL)
+	}
+
+	heading("Summary")
+	buf.WriteString(wrapText(data.basics.Summary, resumeTextWidth, ""))
+
+	heading("Skills")
+	for _, skill := range data.skills {
+		buf.WriteString(wrapText(skill.Level+": "+strings.Join(skill.Keywords, ", "), resumeTextWidth, "  "))
+	}
+
+	heading("Work experience")
+	for i, experience := range data.experiences {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+
+		fmt.Fprintf(&buf, "%s, %s\n", experience.Role, experience.Company)
+		fmt.Fprintf(&buf, "%s\n", experience.dates())
+		if experience.Location != "" {
+			fmt.Fprintf(&buf, "%s\n", experience.Location)
+		}
+
+		for _, paragraph := range experience.descriptionParagraphs() {
+			buf.WriteByte('\n')
+			buf.WriteString(wrapText(paragraph, resumeTextWidth, ""))
+		}
+
+		if len(experience.Highlights) > 0 {
+			buf.WriteByte('\n')
+			for _, highlight := range experience.Highlights {
+				buf.WriteString(wrapText("* "+highlight, resumeTextWidth, "  "))
+			}
+		}
+		if len(experience.Tech) > 0 {
+			buf.WriteByte('\n')
+			buf.WriteString(wrapText("Tech: "+strings.Join(experience.Tech, ", "), resumeTextWidth, "  "))
+		}
+	}
+
+	heading("Side projects")
+	for _, project := range data.sideProjects {
+		buf.WriteString(wrapText(fmt.Sprintf("* %s (%s): %s", project.Name, project.URL, project.Description), resumeTextWidth, "  "))
+	}
+
+	heading("Interests")
+	for _, interest := range data.basics.Interests {
+		buf.WriteString(wrapText(interest, resumeTextWidth, ""))
+	}
+
+	return buf.Bytes()
+}
+
+// exportMarkdownResume returns the resume as a single markdown document.
+//
+// The markdown of the skills, side projects and work experience descriptions is copied as is.
+func exportMarkdownResume(data resume) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "# %s\n\n", data.basics.Name)
+	fmt.Fprintf(&buf, "%s\n\n", data.basics.Label)
+	fmt.Fprintf(&buf, "* Email: <%s>\n", data.basics.Email)
+	fmt.Fprintf(&buf, "* Website: <%s>\n", data.basics.Website)
+	for _, profile := range data.basics.Profiles {
+		fmt.Fprintf(&buf, "* %s: <%s>\n", profile.Network, profile.URL)
+	}
+
+	fmt.Fprintf(&buf, "\n## Summary\n\n%s\n", data.basics.Summary)
+
+	if data.skillsPart != nil {
+		fmt.Fprintf(&buf, "\n%s", markdownBody(data.skillsPart.sourceData))
+	}
+
+	buf.WriteString("\n## Work experience\n")
+	for _, experience := range data.experiences {
+		fmt.Fprintf(&buf, "\n### %s, %s\n\n", experience.Role, experience.Company)
+		fmt.Fprintf(&buf, "%s", experience.dates())
+		if experience.Location != "" {
+			fmt.Fprintf(&buf, " · %s", experience.Location)
+		}
+		buf.WriteString("\n\n")
+
+		buf.Write(markdownBody(experience.page.sourceData))
+
+		if len(experience.Highlights) > 0 {
+			buf.WriteByte('\n')
+			for _, highlight := range experience.Highlights {
+				fmt.Fprintf(&buf, "* %s\n", highlight)
+			}
+		}
+		if len(experience.Tech) > 0 {
+			fmt.Fprintf(&buf, "\n**Tech:** %s\n", strings.Join(experience.Tech, ", "))
+		}
+	}
+
+	if data.sideProjectsPart != nil {
+		fmt.Fprintf(&buf, "\n%s", markdownBody(data.sideProjectsPart.sourceData))
+	}
+
+	buf.WriteString("\n## Interests\n\n")
+	for i, interest := range data.basics.Interests {
+		if i > 0 {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, "%s\n", interest)
+	}
+
+	return buf.Bytes()
+}
+
+// dates returns the dates of the experience, like `2013/12 - 2023/02` or `2023/02 - Present`.
+func (e resumeExperience) dates() string {
+	end := "Present"
+	if !e.End.IsZero() {
+		end = e.End.Format(resumeDateLayout)
+	}
+	return e.Start.Format(resumeDateLayout) + " - " + end
+}
+
+// descriptionParagraphs returns the text of each block of the description.
+func (e resumeExperience) descriptionParagraphs() []string {
+	var res []string
+	for n := e.page.markdownDocument.FirstChild(); n != nil; n = n.NextSibling() {
+		if _, ok := n.(*goldmarkast.Heading); ok {
+			continue
+		}
+		if text := strings.TrimSpace(extractText(n, e.page.sourceData)); text != "" {
+			res = append(res, text)
+		}
+	}
+	return res
+}
+
+// markdownBody returns the markdown source without the front matter, with a single trailing newline.
+func markdownBody(source []byte) []byte {
+	if rest, ok := bytes.CutPrefix(source, []byte("---\n")); ok {
+		if _, body, ok := bytes.Cut(rest, []byte("\n---\n")); ok {
+			source = body
+		}
+	}
+	return append(bytes.TrimSpace(source), '\n')
+}
+
+// wrapText wraps text at width columns, indenting the continuation lines with indent.
+func wrapText(text string, width int, indent string) string {
+	var (
+		buf        strings.Builder
+		lineLength int
+	)
+
+	for i, word := range strings.Fields(text) {
+		wordLength := len([]rune(word))
+
+		switch {
+		case i == 0:
+		case lineLength+1+wordLength > width:
+			buf.WriteString("\n" + indent)
+			lineLength = len(indent)
+		default:
+			buf.WriteByte(' ')
+			lineLength++
+		}
+
+		buf.WriteString(word)
+		lineLength += wordLength
+	}
+	buf.WriteByte('\n')
+
+	return buf.String()
+}
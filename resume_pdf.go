@@ -0,0 +1,201 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// Layout of the PDF resume, in millimeters and points.
+const (
+	resumePDFMargin      = 18.0
+	resumePDFLineHeight  = 5.0
+	resumePDFFontSize    = 10.0
+	resumePDFHeadingSize = 13.0
+)
+
+// resumePDFAccentColor is the color of the section headings and company names, the same as the HTML resume.
+var resumePDFAccentColor = [3]int{0xeb, 0x1e, 0x2b}
+
+// renderResumePDF lays out the resume as an A4 PDF document.
+//
+// Only the core PDF fonts are used so no font file is needed; the text is translated to the cp1252 encoding they support.
+//
+// The output only depends on the resume and on date, which is used as the creation and modification date of the document.
+func renderResumePDF(w io.Writer, data resume, date time.Time) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetCatalogSort(true)
+	pdf.SetCreationDate(date)
+	pdf.SetModificationDate(date)
+	pdf.SetMargins(resumePDFMargin, resumePDFMargin, resumePDFMargin)
+	pdf.SetAutoPageBreak(true, resumePDFMargin)
+
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetTitle(tr(data.basics.Name+" - Resume"), false)
+	pdf.SetAuthor(tr(data.basics.Name), false)
+
+	pdf.AddPage()
+
+	pageWidth, pageHeight := pdf.GetPageSize()
+	contentWidth := pageWidth - 2*resumePDFMargin
+
+	// keepWithNext starts a new page if less than height is left, to not leave a heading alone at the bottom of a page
+	keepWithNext := func(height float64) {
+		if pdf.GetY()+height > pageHeight-resumePDFMargin {
+			pdf.AddPage()
+		}
+	}
+
+	setFont := func(style string, size float64) {
+		pdf.SetFont("Helvetica", style, size)
+	}
+	paragraph := func(text string) {
+		setFont("", resumePDFFontSize)
+		pdf.MultiCell(contentWidth, resumePDFLineHeight, tr(text), "", "L", false)
+	}
+	bullet := func(write func()) {
+		setFont("", resumePDFFontSize)
+		pdf.SetX(resumePDFMargin + 2)
+		pdf.Write(resumePDFLineHeight, tr("•  "))
+		pdf.SetLeftMargin(pdf.GetX())
+		write()
+		pdf.SetLeftMargin(resumePDFMargin)
+		pdf.Ln(resumePDFLineHeight)
+	}
+	heading := func(title string) {
+		pdf.Ln(4)
+		keepWithNext(25)
+		setFont("B", resumePDFHeadingSize)
+		pdf.SetTextColor(resumePDFAccentColor[0], resumePDFAccentColor[1], resumePDFAccentColor[2])
+		pdf.CellFormat(contentWidth, 7, tr(title), "", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+
+		pdf.SetDrawColor(200, 200, 200)
+		pdf.Line(resumePDFMargin, pdf.GetY(), pageWidth-resumePDFMargin, pdf.GetY())
+		pdf.Ln(2)
+	}
+
+	// Header: name and label on the left, contacts on the right
+
+	headerY := pdf.GetY()
+
+	setFont("B", 22)
+	pdf.CellFormat(contentWidth, 10, tr(data.basics.Name), "", 1, "L", false, 0, "")
+	setFont("I", resumePDFHeadingSize)
+	pdf.CellFormat(contentWidth, 7, tr(data.basics.Label), "", 1, "L", false, 0, "")
+	afterTitleY := pdf.GetY()
+
+	type contact struct {
+		text string
+		url  string
+	}
+
+	contacts := []contact{
+		{data.basics.Email, "mailto:" + data.basics.Email},
+		{strings.TrimPrefix(data.basics.Website, "https://"), data.basics.Website},
+	}
+	for _, profile := range data.basics.Profiles {
+		contacts = append(contacts, contact{strings.TrimPrefix(profile.URL, "https://"), profile.URL})
+	}
+
+	pdf.SetY(headerY)
+	setFont("", resumePDFFontSize)
+	for _, contact := range contacts {
+		pdf.CellFormat(contentWidth, resumePDFLineHeight, tr(contact.text), "", 1, "R", false, 0, contact.url)
+	}
+	pdf.SetY(max(pdf.GetY(), afterTitleY))
+
+	// Sections
+
+	heading("Summary")
+	paragraph(data.basics.Summary)
+
+	if len(data.skills) > 0 {
+		heading("Skills")
+		for _, skill := range data.skills {
+			bullet(func() {
+				setFont("B", resumePDFFontSize)
+				pdf.Write(resumePDFLineHeight, tr(skill.Level+" "))
+				setFont("", resumePDFFontSize)
+				pdf.Write(resumePDFLineHeight, tr(strings.Join(skill.Keywords, ", ")))
+			})
+		}
+	}
+
+	heading("Work experience")
+	for i, experience := range data.experiences {
+		if i > 0 {
+			pdf.Ln(3)
+		}
+		keepWithNext(25)
+
+		setFont("B", 11)
+		pdf.SetTextColor(resumePDFAccentColor[0], resumePDFAccentColor[1], resumePDFAccentColor[2])
+		pdf.CellFormat(contentWidth/2, 6, tr(experience.Company), "", 0, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+		setFont("", resumePDFFontSize)
+		pdf.CellFormat(contentWidth/2, 6, tr(experience.dates()), "", 1, "R", false, 0, "")
+
+		role := experience.Role
+		if experience.Location != "" {
+			role += ", " + experience.Location
+		}
+		setFont("I", 11)
+		pdf.CellFormat(contentWidth, 6, tr(role), "", 1, "L", false, 0, "")
+		pdf.Ln(1)
+
+		for j, text := range experience.descriptionParagraphs() {
+			if j > 0 {
+				pdf.Ln(1.5)
+			}
+			paragraph(text)
+		}
+
+		if len(experience.Highlights) > 0 {
+			pdf.Ln(1.5)
+			for _, highlight := range experience.Highlights {
+				bullet(func() {
+					pdf.Write(resumePDFLineHeight, tr(highlight))
+				})
+			}
+		}
+
+		if len(experience.Tech) > 0 {
+			pdf.Ln(1.5)
+			setFont("B", resumePDFFontSize)
+			pdf.Write(resumePDFLineHeight, "Tech: ")
+			setFont("", resumePDFFontSize)
+			pdf.Write(resumePDFLineHeight, tr(strings.Join(experience.Tech, ", ")))
+			pdf.Ln(resumePDFLineHeight)
+		}
+	}
+
+	if len(data.sideProjects) > 0 {
+		heading("Side projects")
+		for _, project := range data.sideProjects {
+			bullet(func() {
+				setFont("B", resumePDFFontSize)
+				pdf.WriteLinkString(resumePDFLineHeight, tr(project.Name), project.URL)
+				setFont("", resumePDFFontSize)
+				pdf.Write(resumePDFLineHeight, tr(", "+project.Description))
+			})
+		}
+	}
+
+	if len(data.basics.Interests) > 0 {
+		heading("Interests")
+		for _, interest := range data.basics.Interests {
+			paragraph(interest)
+		}
+	}
+
+	if err := pdf.Output(w); err != nil {
+		return fmt.Errorf("unable to write PDF resume, err: %w", err)
+	}
+
+	return nil
+}
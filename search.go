@@ -0,0 +1,245 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/kljensen/snowball/english"
+	"github.com/kljensen/snowball/french"
+	goldmarkast "github.com/yuin/goldmark/ast"
+
+	"go.rischmann.fr/website-generator/templates"
+)
+
+// searchIndexVersion is the version of the search index format.
+//
+// It must be incremented whenever the format changes, and kept in sync with `assets/search.js`.
+const searchIndexVersion = 2
+
+// Weight of a term depending on where it's found.
+const (
+	searchWeightTitle   = 10
+	searchWeightHeading = 5
+	searchWeightBody    = 1
+)
+
+type searchDocument struct {
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Date  string `json:"date,omitempty"`
+}
+
+// searchIndex is an inverted index mapping stemmed terms to the documents containing them.
+type searchIndex struct {
+	Version   int              `json:"version"`
+	Documents []searchDocument `json:"documents"`
+	// Terms maps a stemmed term to a list of (document index, score) pairs, sorted by descending score.
+	Terms map[string][][2]int `json:"terms"`
+	// StopWords are the words found in the pages but not indexed, they must be ignored in queries.
+	StopWords []string `json:"stop_words"`
+	// Stems maps a word to its stemmed term when the search can't find the term from the word by prefix,
+	// for example "using" is indexed as "use".
+	Stems map[string]string `json:"stems"`
+}
+
+type searchStemmer struct {
+	stem       func(word string, stemStopWords bool) string
+	isStopWord func(word string) bool
+}
+
+var searchStemmers = map[string]searchStemmer{
+	"en": {english.Stem, english.IsStopWord},
+	"fr": {french.Stem, french.IsStopWord},
+}
+
+// buildSearchIndex builds the search index of all rendered pages from their markdown document.
+//
+// The language used for stemming is taken from the `lang` front matter value, English by default.
+func buildSearchIndex(pages pages) (*searchIndex, error) {
+	res := &searchIndex{
+		Version: searchIndexVersion,
+		Terms:   make(map[string][][2]int),
+		Stems:   make(map[string]string),
+	}
+	stopWords := make(map[string]struct{})
+
+	for _, page := range pages {
+		if !page.isRendered() {
+			continue
+		}
+
+		lang := "en"
+		if tmp, ok := page.metadata.Extra["lang"]; ok {
+			lang, _ = tmp.(string)
+		}
+		stemmer, ok := searchStemmers[lang]
+		if !ok {
+			return nil, fmt.Errorf("invalid `lang` value %v in page %s, should be one of \"en\" or \"fr\"", page.metadata.Extra["lang"], page.sourcePath)
+		}
+
+		// Compute the score of every term of the page
+		scores := make(map[string]int)
+		addTerms := func(text string, weight int) {
+			for _, word := range tokenizeSearchText(text) {
+				if stemmer.isStopWord(word) {
+					stopWords[word] = struct{}{}
+					continue
+				}
+
+				stem := stemmer.stem(word, false)
+				if !searchTermMatches(stem, word) {
+					res.Stems[word] = stem
+				}
+				scores[stem] += weight
+			}
+		}
+
+		addTerms(page.metadata.Title, searchWeightTitle)
+
+		var body strings.Builder
+		goldmarkast.Walk(page.markdownDocument, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+			if !entering {
+				return goldmarkast.WalkContinue, nil
+			}
+
+			switch n := n.(type) {
+			case *goldmarkast.Heading:
+				addTerms(extractText(n, page.sourceData), searchWeightHeading)
+				return goldmarkast.WalkSkipChildren, nil
+
+			case *goldmarkast.Paragraph, *goldmarkast.TextBlock:
+				body.WriteString(extractText(n, page.sourceData))
+				body.WriteByte(' ')
+				return goldmarkast.WalkSkipChildren, nil
+
+			case *figureNode:
+				body.Write(n.Caption)
+				body.WriteByte(' ')
+				return goldmarkast.WalkSkipChildren, nil
+			}
+
+			return goldmarkast.WalkContinue, nil
+		})
+		addTerms(body.String(), searchWeightBody)
+
+		// Add the page to the index
+		document := searchDocument{
+			URL:   page.url(),
+			Title: page.metadata.Title,
+		}
+		if !page.metadata.Date.IsZero() {
+			document.Date = page.metadata.Date.Format("2006 Jan 02")
+		}
+
+		documentIndex := len(res.Documents)
+		res.Documents = append(res.Documents, document)
+
+		for term, score := range scores {
+			res.Terms[term] = append(res.Terms[term], [2]int{documentIndex, score})
+		}
+	}
+
+	res.StopWords = slices.Sorted(maps.Keys(stopWords))
+
+	for _, postings := range res.Terms {
+		slices.SortFunc(postings, func(a, b [2]int) int {
+			return cmp.Or(
+				cmp.Compare(b[1], a[1]),
+				cmp.Compare(a[0], b[0]),
+			)
+		})
+	}
+
+	return res, nil
+}
+
+// searchTermMatches returns true if a query word matches a term by prefix.
+//
+// This must match the matching done in `assets/search.js`.
+func searchTermMatches(term string, word string) bool {
+	return strings.HasPrefix(term, word) || (utf8.RuneCountInString(term) >= 3 && strings.HasPrefix(word, term))
+}
+
+// tokenizeSearchText splits a text into lower cased words.
+//
+// This must match the tokenization done in `assets/search.js`.
+func tokenizeSearchText(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+
+	return slices.DeleteFunc(words, func(word string) bool {
+		return len([]rune(word)) < 2
+	})
+}
+
+func generateSearch(logger *slog.Logger, generationDate time.Time, buildRootDir string, pages pages) error {
+	ctx := context.Background()
+
+	// Generate the index
+
+	index, err := buildSearchIndex(pages)
+	if err != nil {
+		return err
+	}
+
+	indexData, err := json.Marshal(index)
+	if err != nil {
+		return fmt.Errorf("unable to marshal search index, err: %w", err)
+	}
+
+	indexName := "search-index.json"
+	if !generationDate.IsZero() {
+		indexName, _ = renameWithVersion(indexName, generationDate)
+	}
+
+	logger.Info("generating search index",
+		slog.Int("documents", len(index.Documents)),
+		slog.Int("terms", len(index.Terms)),
+	)
+
+	if err := writeOutputFile(buildRootDir, "assets/"+indexName, string(indexData)); err != nil {
+		return err
+	}
+
+	// Generate the search page
+
+	assets := newAssets(generationDate)
+	assets.add("style.css")
+	assets.add("app.js")
+	assets.add("search.js")
+
+	page := templates.Page(
+		templates.HeaderParams{
+			Title:       "Vincent Rischmann - Search",
+			Description: "",
+		},
+		assets.underlying,
+		templates.Search("/assets/"+indexName),
+	)
+
+	f, err := createOutputFile(buildRootDir, "search.html")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	logger.Info("generating search page",
+		slog.String("output_path", f.Name()),
+	)
+
+	if err := page.Render(ctx, f); err != nil {
+		return fmt.Errorf("unable to render page to file %q, err: %w", f.Name(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTokenizeSearchText(t *testing.T) {
+	// The expected words are the result of the `tokenize` function of `assets/search.js`
+	testCases := []struct {
+		text string
+		want []string
+	}{
+		{text: "Waking up my NAS remotely", want: []string{"waking", "up", "my", "nas", "remotely"}},
+		{text: "L'été à Lyon, c’était chaud", want: []string{"été", "lyon", "était", "chaud"}},
+		{text: "x² and ½ cup", want: []string{"x²", "and", "cup"}},
+		{text: "Go 1.24: zig-sqlite", want: []string{"go", "24", "zig", "sqlite"}},
+		{text: "ÉCOLE Straße", want: []string{"école", "straße"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.text, func(t *testing.T) {
+			if got := tokenizeSearchText(tc.text); !slices.Equal(got, tc.want) {
+				t.Errorf("got %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// searchQueryTerms returns the terms of the index matching a query word, like `matchingTerms` in `assets/search.js`.
+func searchQueryTerms(index *searchIndex, word string) []string {
+	if slices.Contains(index.StopWords, word) {
+		return nil
+	}
+
+	stem, hasStem := index.Stems[word]
+
+	var res []string
+	for term := range index.Terms {
+		if (hasStem && term == stem) || searchTermMatches(term, word) {
+			res = append(res, term)
+		}
+	}
+	return res
+}
+
+func TestSearchIndexQueries(t *testing.T) {
+	sources := map[string]string{
+		"en.md": "---\nformat: standard\ntitle: Waking up my NAS remotely using Tailscale\n---\n" +
+			"The servers were running and the connections happily succeeded, I used studies about mice and geese.\n",
+		"fr.md": "---\nformat: standard\ntitle: Les chevaux de l'été\nlang: fr\n---\n" +
+			"Nous étions heureux, les châteaux étaient beaux et les journaux parlaient des animaux.\n",
+	}
+	pages, _ := collectTestPages(t, sources)
+
+	index, err := buildSearchIndex(pages)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every word of the pages must find the term it was indexed as
+	for _, page := range pages {
+		lang := "en"
+		if tmp, ok := page.metadata.Extra["lang"]; ok {
+			lang = tmp.(string)
+		}
+		stemmer := searchStemmers[lang]
+
+		text := page.metadata.Title + " " + extractText(page.markdownDocument, page.sourceData)
+		for _, word := range tokenizeSearchText(text) {
+			terms := searchQueryTerms(index, word)
+
+			if stemmer.isStopWord(word) {
+				if len(terms) > 0 {
+					t.Errorf("stop word %q of page %s matches terms %q", word, page.sourcePath, terms)
+				}
+				continue
+			}
+
+			stem := stemmer.stem(word, false)
+			if !slices.Contains(terms, stem) {
+				t.Errorf("word %q of page %s indexed as %q doesn't match it, it matches %q", word, page.sourcePath, stem, terms)
+			}
+		}
+	}
+
+	// Irregular stems are found through the stems of the index
+	for word, stem := range map[string]string{"using": "use", "chevaux": "cheval"} {
+		if got := index.Stems[word]; got != stem {
+			t.Errorf("got stem %q for %q, want %q", got, word, stem)
+		}
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"cmp"
+	"slices"
+
+	"go.rischmann.fr/website-generator/templates"
+)
+
+// collectBlogNavigation computes the navigation of every blog entry:
+// * the previous and next entries in chronological order
+// * the list of parts if the entry is part of a series
+//
+// Parts of a series are sorted by their `series_order` value, then by date.
+func collectBlogNavigation(pages pages) map[string]templates.BlogNavigation {
+	entries := pages.getAll(formatBlogEntry)
+	slices.SortStableFunc(entries, func(a, b page) int {
+		return a.metadata.Date.Compare(b.metadata.Date)
+	})
+
+	series := make(map[string][]page)
+	for _, entry := range entries {
+		if name := entry.metadata.Series; name != "" {
+			series[name] = append(series[name], entry)
+		}
+	}
+	for _, parts := range series {
+		slices.SortStableFunc(parts, func(a, b page) int {
+			return cmp.Or(
+				cmp.Compare(a.metadata.SeriesOrder, b.metadata.SeriesOrder),
+				a.metadata.Date.Compare(b.metadata.Date),
+			)
+		})
+	}
+
+	res := make(map[string]templates.BlogNavigation, len(entries))
+	for i, entry := range entries {
+		var navigation templates.BlogNavigation
+
+		if i > 0 {
+			navigation.Previous = &templates.Link{
+				URL:  entries[i-1].url(),
+				Text: entries[i-1].metadata.Title,
+			}
+		}
+		if i < len(entries)-1 {
+			navigation.Next = &templates.Link{
+				URL:  entries[i+1].url(),
+				Text: entries[i+1].metadata.Title,
+			}
+		}
+
+		if name := entry.metadata.Series; name != "" {
+			navigation.Series = &templates.Series{Name: name}
+			for _, part := range series[name] {
+				navigation.Series.Parts = append(navigation.Series.Parts, templates.SeriesPart{
+					Link: templates.Link{
+						URL:  part.url(),
+						Text: part.metadata.Title,
+					},
+					Current: part.path == entry.path,
+				})
+			}
+		}
+
+		res[entry.url()] = navigation
+	}
+
+	return res
+}
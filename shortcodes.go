@@ -0,0 +1,419 @@
+package main
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/a-h/templ"
+	"github.com/yuin/goldmark"
+	goldmarkast "github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	goldmarkrenderer "github.com/yuin/goldmark/renderer"
+	goldmarktext "github.com/yuin/goldmark/text"
+	goldmarkutil "github.com/yuin/goldmark/util"
+
+	"go.rischmann.fr/website-generator/templates"
+)
+
+// shortcodeParam is a parameter of a shortcode.
+type shortcodeParam struct {
+	name     string
+	required bool
+	// pattern validates the value, any value is valid if nil
+	pattern *regexp.Regexp
+	// description is shown in the error when the value doesn't match the pattern
+	description string
+}
+
+// shortcode maps a shortcode to the templ component rendering it.
+type shortcode struct {
+	params    []shortcodeParam
+	component func(args map[string]string) templ.Component
+	// block is true if the component renders a block element, the shortcode must then be alone on its line
+	block bool
+}
+
+// shortcodes are the registered shortcodes, usable in markdown with `{{< name arg1 arg2 key="value" >}}`.
+//
+// Arguments are either positional, in the order of the parameters, or named.
+var shortcodes = map[string]shortcode{
+	"youtube": {
+		params: []shortcodeParam{
+			{name: "id", required: true, pattern: regexp.MustCompile(`^[A-Za-z0-9_-]{11}$`), description: "a YouTube video ID"},
+			{name: "title"},
+		},
+		block: true,
+		component: func(args map[string]string) templ.Component {
+			return templates.YouTube(args["id"], cmp.Or(args["title"], "YouTube video"))
+		},
+	},
+	"asciinema": {
+		params: []shortcodeParam{
+			{name: "id", required: true, pattern: regexp.MustCompile(`^[A-Za-z0-9]+$`), description: "an asciinema recording ID"},
+		},
+		block: true,
+		component: func(args map[string]string) templ.Component {
+			return templates.Asciinema(args["id"])
+		},
+	},
+	"repo": {
+		params: []shortcodeParam{
+			{name: "repo", required: true, pattern: regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`), description: "a GitHub repository like `owner/name`"},
+			{name: "description"},
+		},
+		component: func(args map[string]string) templ.Component {
+			owner, name, _ := strings.Cut(args["repo"], "/")
+			return templates.RepoCard(owner, name, args["description"])
+		},
+	},
+	"pdf": {
+		params: []shortcodeParam{
+			{name: "url", required: true, pattern: regexp.MustCompile(`^/.+\.pdf$`), description: "an absolute path to a PDF file like `/files/talk.pdf`"},
+			{name: "title"},
+		},
+		block: true,
+		component: func(args map[string]string) templ.Component {
+			return templates.PDF(args["url"], cmp.Or(args["title"], "PDF document"))
+		},
+	},
+}
+
+// newShortcodeComponent returns the component of a shortcode call, the content between `{{<` and `>}}`.
+//
+// inline is true if the shortcode is inside a paragraph, which is invalid for a block shortcode.
+func newShortcodeComponent(call string, inline bool) (templ.Component, error) {
+	tokens, err := tokenizeShortcode(call)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("missing shortcode name")
+	}
+
+	name := tokens[0]
+	shortcode, ok := shortcodes[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown shortcode %q", name)
+	}
+	if inline && shortcode.block {
+		return nil, fmt.Errorf("shortcode %q can't be inside a paragraph, it must be alone on its line", name)
+	}
+
+	// Bind the arguments to the parameters
+
+	args := make(map[string]string)
+	position := 0
+	for _, token := range tokens[1:] {
+		key, value, named := strings.Cut(token, "=")
+		if named && isShortcodeParamName(key) {
+			value, err := unquoteShortcodeValue(value)
+			if err != nil {
+				return nil, err
+			}
+
+			if !slices.ContainsFunc(shortcode.params, func(param shortcodeParam) bool { return param.name == key }) {
+				return nil, fmt.Errorf("unknown argument %q for shortcode %q", key, name)
+			}
+			if _, ok := args[key]; ok {
+				return nil, fmt.Errorf("duplicate argument %q for shortcode %q", key, name)
+			}
+			args[key] = value
+			continue
+		}
+
+		if position >= len(shortcode.params) {
+			return nil, fmt.Errorf("too many arguments for shortcode %q, expected at most %d", name, len(shortcode.params))
+		}
+
+		value, err := unquoteShortcodeValue(token)
+		if err != nil {
+			return nil, err
+		}
+
+		param := shortcode.params[position]
+		if _, ok := args[param.name]; ok {
+			return nil, fmt.Errorf("duplicate argument %q for shortcode %q", param.name, name)
+		}
+		args[param.name] = value
+		position++
+	}
+
+	// Validate the arguments
+
+	for _, param := range shortcode.params {
+		value, ok := args[param.name]
+		if !ok {
+			if param.required {
+				return nil, fmt.Errorf("missing argument %q for shortcode %q", param.name, name)
+			}
+			continue
+		}
+		if param.pattern != nil && !param.pattern.MatchString(value) {
+			return nil, fmt.Errorf("invalid argument %q value %q for shortcode %q, should be %s", param.name, value, name, param.description)
+		}
+	}
+
+	return shortcode.component(args), nil
+}
+
+func isShortcodeParamName(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' {
+			return false
+		}
+	}
+	return true
+}
+
+func unquoteShortcodeValue(value string) (string, error) {
+	if !strings.HasPrefix(value, `"`) {
+		return value, nil
+	}
+	res, err := strconv.Unquote(value)
+	if err != nil {
+		return "", fmt.Errorf("invalid quoted string %s", value)
+	}
+	return res, nil
+}
+
+// tokenizeShortcode splits a shortcode call on spaces, keeping double quoted strings together.
+func tokenizeShortcode(call string) ([]string, error) {
+	var (
+		res     []string
+		current strings.Builder
+		quoted  bool
+		escaped bool
+	)
+
+	for _, r := range call {
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && r == '\\':
+			escaped = true
+		case r == '"':
+			quoted = !quoted
+		case !quoted && unicode.IsSpace(r):
+			if current.Len() > 0 {
+				res = append(res, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteRune(r)
+	}
+
+	if quoted {
+		return nil, fmt.Errorf("unterminated quoted string")
+	}
+	if current.Len() > 0 {
+		res = append(res, current.String())
+	}
+
+	return res, nil
+}
+
+var (
+	shortcodeOpen  = []byte("{{<")
+	shortcodeClose = []byte(">}}")
+)
+
+// parseShortcodeCall returns the call of the shortcode at the beginning of line and the length of the shortcode.
+func parseShortcodeCall(line []byte) (string, int, bool) {
+	if !bytes.HasPrefix(line, shortcodeOpen) {
+		return "", 0, false
+	}
+	end := bytes.Index(line, shortcodeClose)
+	if end < 0 {
+		return "", 0, false
+	}
+	return string(line[len(shortcodeOpen):end]), end + len(shortcodeClose), true
+}
+
+// shortcodeNode is a shortcode inside a paragraph.
+type shortcodeNode struct {
+	goldmarkast.BaseInline
+
+	Call      string
+	Component templ.Component
+}
+
+var kindShortcode = goldmarkast.NewNodeKind("Shortcode")
+
+func (n *shortcodeNode) Kind() goldmarkast.NodeKind { return kindShortcode }
+
+func (n *shortcodeNode) Dump(source []byte, level int) {
+	goldmarkast.DumpHelper(n, source, level, map[string]string{
+		"Call": n.Call,
+	}, nil)
+}
+
+// shortcodeBlockNode is a shortcode alone on its line, rendered outside of a paragraph.
+type shortcodeBlockNode struct {
+	goldmarkast.BaseBlock
+
+	Call      string
+	Component templ.Component
+}
+
+var kindShortcodeBlock = goldmarkast.NewNodeKind("ShortcodeBlock")
+
+func (n *shortcodeBlockNode) Kind() goldmarkast.NodeKind { return kindShortcodeBlock }
+
+func (n *shortcodeBlockNode) Dump(source []byte, level int) {
+	goldmarkast.DumpHelper(n, source, level, map[string]string{
+		"Call": n.Call,
+	}, nil)
+}
+
+func newShortcodeError(source []byte, offset int, call string, err error) error {
+	return fmt.Errorf("line %d: invalid shortcode {{<%s>}}, err: %w", lineNumber(source, offset), call, err)
+}
+
+// shortcodeInlineParser is a goldmarkparser.InlineParser parsing shortcodes inside a paragraph.
+type shortcodeInlineParser struct{}
+
+func (p shortcodeInlineParser) Trigger() []byte {
+	return []byte{'{'}
+}
+
+func (p shortcodeInlineParser) Parse(parent goldmarkast.Node, block goldmarktext.Reader, pc goldmarkparser.Context) goldmarkast.Node {
+	line, segment := block.PeekLine()
+
+	call, length, ok := parseShortcodeCall(line)
+	if !ok {
+		return nil
+	}
+	block.Advance(length)
+
+	component, err := newShortcodeComponent(call, true)
+	if err != nil {
+		addTransformError(pc, newShortcodeError(block.Source(), segment.Start, call, err))
+	}
+
+	return &shortcodeNode{
+		Call:      call,
+		Component: component,
+	}
+}
+
+var _ goldmarkparser.InlineParser = shortcodeInlineParser{}
+
+// shortcodeBlockParser is a goldmarkparser.BlockParser parsing a shortcode alone on its line.
+type shortcodeBlockParser struct{}
+
+func (p shortcodeBlockParser) Trigger() []byte {
+	return []byte{'{'}
+}
+
+func (p shortcodeBlockParser) Open(parent goldmarkast.Node, reader goldmarktext.Reader, pc goldmarkparser.Context) (goldmarkast.Node, goldmarkparser.State) {
+	line, segment := reader.PeekLine()
+	if pc.BlockOffset() < 0 {
+		return nil, goldmarkparser.NoChildren
+	}
+
+	trimmed := bytes.TrimSpace(line)
+
+	call, length, ok := parseShortcodeCall(trimmed)
+	if !ok || length != len(trimmed) {
+		return nil, goldmarkparser.NoChildren
+	}
+
+	component, err := newShortcodeComponent(call, false)
+	if err != nil {
+		addTransformError(pc, newShortcodeError(reader.Source(), segment.Start, call, err))
+	}
+
+	reader.Advance(lineLengthWithoutNewline(line, segment))
+
+	return &shortcodeBlockNode{
+		Call:      call,
+		Component: component,
+	}, goldmarkparser.NoChildren
+}
+
+func (p shortcodeBlockParser) Continue(node goldmarkast.Node, reader goldmarktext.Reader, pc goldmarkparser.Context) goldmarkparser.State {
+	return goldmarkparser.Close
+}
+
+func (p shortcodeBlockParser) Close(node goldmarkast.Node, reader goldmarktext.Reader, pc goldmarkparser.Context) {
+}
+
+// CanInterruptParagraph returns true so that a shortcode on the line after a paragraph isn't parsed as part of it.
+func (p shortcodeBlockParser) CanInterruptParagraph() bool {
+	return true
+}
+
+func (p shortcodeBlockParser) CanAcceptIndentedLine() bool {
+	return false
+}
+
+var _ goldmarkparser.BlockParser = shortcodeBlockParser{}
+
+// shortcodeRenderer is a goldmarkrenderer.NodeRenderer rendering the component of a shortcode.
+type shortcodeRenderer struct{}
+
+func (r shortcodeRenderer) RegisterFuncs(reg goldmarkrenderer.NodeRendererFuncRegisterer) {
+	reg.Register(kindShortcode, r.renderShortcode)
+	reg.Register(kindShortcodeBlock, r.renderShortcode)
+}
+
+func (r shortcodeRenderer) renderShortcode(w goldmarkutil.BufWriter, source []byte, node goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+	if !entering {
+		return goldmarkast.WalkSkipChildren, nil
+	}
+
+	var component templ.Component
+	switch n := node.(type) {
+	case *shortcodeNode:
+		component = n.Component
+	case *shortcodeBlockNode:
+		component = n.Component
+	}
+	if component == nil {
+		// The shortcode is invalid, the error is already reported by the parser
+		return goldmarkast.WalkSkipChildren, nil
+	}
+
+	if err := component.Render(context.Background(), w); err != nil {
+		return goldmarkast.WalkStop, fmt.Errorf("unable to render shortcode, err: %w", err)
+	}
+	if node.Type() == goldmarkast.TypeBlock {
+		_ = w.WriteByte('\n')
+	}
+
+	return goldmarkast.WalkSkipChildren, nil
+}
+
+var _ goldmarkrenderer.NodeRenderer = shortcodeRenderer{}
+
+// shortcodeExtension adds shortcodes, see [shortcodes].
+type shortcodeExtension struct{}
+
+func (e shortcodeExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		goldmarkparser.WithBlockParsers(
+			goldmarkutil.Prioritized(shortcodeBlockParser{}, 650),
+		),
+		goldmarkparser.WithInlineParsers(
+			goldmarkutil.Prioritized(shortcodeInlineParser{}, 500),
+		),
+	)
+	m.Renderer().AddOptions(
+		goldmarkrenderer.WithNodeRenderers(
+			goldmarkutil.Prioritized(shortcodeRenderer{}, 500),
+		),
+	)
+}
+
+var _ goldmark.Extender = shortcodeExtension{}
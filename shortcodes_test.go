@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBlockShortcode(t *testing.T) {
+	testCases := []struct {
+		name   string
+		source string
+		// wantErr is true if the shortcode is rejected because it's inside a paragraph
+		wantErr bool
+	}{
+		{
+			name:   "alone",
+			source: "{{< youtube \"dQw4w9WgXcQ\" >}}\n",
+		},
+		{
+			name:   "after a paragraph",
+			source: "Some text\n{{< pdf \"/files/talk.pdf\" >}}\n",
+		},
+		{
+			name:    "inline",
+			source:  "Watch {{< youtube \"dQw4w9WgXcQ\" >}} now\n",
+			wantErr: true,
+		},
+		{
+			name:    "followed by text",
+			source:  "{{< asciinema \"123456\" >}} and more\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			output, err := convertMarkdown(t, tc.source, shortcodeExtension{})
+			if tc.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, output: %s", output)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if strings.Contains(output, "<p><div") {
+				t.Errorf("block shortcode rendered inside a paragraph, output: %s", output)
+			}
+		})
+	}
+}
+
+func TestInlineShortcode(t *testing.T) {
+	output, err := convertMarkdown(t, "See {{< repo \"vrischmann/zig-sqlite\" >}} for details\n", shortcodeExtension{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(output, "<p>See <a class=\"repo-card\"") {
+		t.Errorf("repo shortcode not rendered inside the paragraph, output: %s", output)
+	}
+}
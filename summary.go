@@ -0,0 +1,139 @@
+package main
+
+import (
+	"html"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	goldmarkast "github.com/yuin/goldmark/ast"
+)
+
+const (
+	// wordsPerMinute is the reading speed used to compute the reading time.
+	wordsPerMinute = 200
+
+	// maxExcerptLength is the maximum length in runes of an excerpt taken from the first paragraph.
+	maxExcerptLength = 300
+
+	// excerptMarker separates the excerpt from the rest of the content.
+	excerptMarker = "<!--more-->"
+)
+
+// pageSummary contains data derived from the content of a page.
+type pageSummary struct {
+	WordCount   int
+	ReadingTime time.Duration
+	// Excerpt is the `description` front matter value if present, otherwise it's derived from the content.
+	Excerpt string
+}
+
+func summarizePage(p page) pageSummary {
+	var res pageSummary
+
+	res.WordCount = len(strings.Fields(extractText(p.markdownDocument, p.sourceData)))
+
+	minutes := (res.WordCount + wordsPerMinute - 1) / wordsPerMinute
+	res.ReadingTime = time.Duration(max(minutes, 1)) * time.Minute
+
+	res.Excerpt = strings.TrimSpace(p.metadata.Description)
+	if res.Excerpt == "" {
+		res.Excerpt = extractExcerpt(p.markdownDocument, p.sourceData)
+	}
+
+	return res
+}
+
+// extractExcerpt returns the text of the blocks before the `<!--more-->` marker if there is one,
+// otherwise the text of the first paragraph, truncated to [maxExcerptLength].
+func extractExcerpt(document goldmarkast.Node, source []byte) string {
+	var (
+		beforeMarker   []string
+		firstParagraph string
+		foundMarker    bool
+	)
+
+	for n := document.FirstChild(); n != nil; n = n.NextSibling() {
+		switch n := n.(type) {
+		case *goldmarkast.HTMLBlock:
+			if isExcerptMarker(n, source) {
+				foundMarker = true
+			}
+		case *goldmarkast.Heading:
+			// Headings are not part of the excerpt
+		default:
+			text := strings.TrimSpace(extractText(n, source))
+			if text == "" {
+				continue
+			}
+			beforeMarker = append(beforeMarker, text)
+			if _, ok := n.(*goldmarkast.Paragraph); ok && firstParagraph == "" {
+				firstParagraph = text
+			}
+		}
+
+		if foundMarker {
+			return strings.Join(beforeMarker, " ")
+		}
+	}
+
+	return truncateText(firstParagraph, maxExcerptLength)
+}
+
+func isExcerptMarker(block *goldmarkast.HTMLBlock, source []byte) bool {
+	var buf strings.Builder
+	lines := block.Lines()
+	for i := range lines.Len() {
+		segment := lines.At(i)
+		buf.Write(segment.Value(source))
+	}
+	return strings.TrimSpace(buf.String()) == excerptMarker
+}
+
+// truncateText truncates text to at most maxLength runes, cutting at a word boundary.
+func truncateText(text string, maxLength int) string {
+	if utf8.RuneCountInString(text) <= maxLength {
+		return text
+	}
+
+	runes := []rune(text)[:maxLength]
+	text = string(runes)
+	if i := strings.LastIndexByte(text, ' '); i > 0 {
+		text = text[:i]
+	}
+
+	return strings.TrimRight(text, " ,;:.") + "…"
+}
+
+// extractText returns the text content of a node, ignoring code blocks and raw HTML.
+func extractText(node goldmarkast.Node, source []byte) string {
+	var buf strings.Builder
+
+	goldmarkast.Walk(node, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+		if !entering {
+			return goldmarkast.WalkContinue, nil
+		}
+
+		switch n := n.(type) {
+		case *goldmarkast.FencedCodeBlock, *goldmarkast.CodeBlock, *goldmarkast.HTMLBlock, *goldmarkast.RawHTML:
+			return goldmarkast.WalkSkipChildren, nil
+		case *goldmarkast.Text:
+			buf.WriteString(html.UnescapeString(string(n.Segment.Value(source))))
+			if n.SoftLineBreak() || n.HardLineBreak() {
+				buf.WriteByte(' ')
+			}
+		case *goldmarkast.String:
+			// The typographer produces HTML entities
+			buf.WriteString(html.UnescapeString(string(n.Value)))
+		default:
+			// Don't join the text of consecutive blocks
+			if n.Type() == goldmarkast.TypeBlock && buf.Len() > 0 {
+				buf.WriteByte(' ')
+			}
+		}
+
+		return goldmarkast.WalkContinue, nil
+	})
+
+	return buf.String()
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"go.rischmann.fr/website-generator/templates"
+)
+
+// talk is a page with the `talk` format, described by its front matter:
+//
+//	format: talk
+//	title: Private Go modules
+//	event: Meetup Golang Lyon
+//	date: 2023 June 01
+//	location: Lyon
+//	slides: golang-lyon-2023-06.pdf
+//	video: https://www.youtube.com/watch?v=...
+//
+// `event` and `date` are required. The slides are a PDF file relative to the page,
+// copied and versioned with the other files of the pages directory.
+type talk struct {
+	Event    string
+	Location string
+	Slides   string
+	Video    string
+
+	page page
+}
+
+func parseTalk(p page) (talk, error) {
+	res := talk{page: p}
+
+	if _, ok := p.metadata.Extra["date"]; !ok {
+		return talk{}, fmt.Errorf("missing `date` value")
+	}
+
+	err := parseStringFields(p.metadata, []stringField{
+		{"event", &res.Event, true},
+		{"location", &res.Location, false},
+		{"slides", &res.Slides, false},
+		{"video", &res.Video, false},
+	})
+	if err != nil {
+		return talk{}, err
+	}
+
+	if res.Slides != "" {
+		if path.Ext(res.Slides) != ".pdf" || path.IsAbs(res.Slides) || !filepath.IsLocal(res.Slides) {
+			return talk{}, fmt.Errorf("invalid `slides` value %q, should be the path of a PDF file relative to the page", res.Slides)
+		}
+	}
+	if res.Video != "" && !strings.HasPrefix(res.Video, "https://") {
+		return talk{}, fmt.Errorf("invalid `video` value %q, should be a https URL", res.Video)
+	}
+
+	return res, nil
+}
+
+// slidesPath returns the path of the slides relative to the pages directory, empty if the talk has no slides.
+func (t talk) slidesPath() string {
+	if t.Slides == "" {
+		return ""
+	}
+	return path.Join(path.Dir(t.page.sourcePath), t.Slides)
+}
+
+// slidesURL returns the absolute URL of the slides, versioned like the file copied by copyVersionedFiles.
+func (t talk) slidesURL(generationDate time.Time) string {
+	if t.Slides == "" {
+		return ""
+	}
+
+	url := "/" + t.slidesPath()
+	if !generationDate.IsZero() {
+		url, _ = renameWithVersion(url, generationDate)
+	}
+
+	return url
+}
+
+func (t talk) templateTalk(generationDate time.Time) templates.Talk {
+	return templates.Talk{
+		URL:      t.page.url(),
+		Title:    t.page.metadata.Title,
+		Event:    t.Event,
+		Date:     t.page.metadata.Date,
+		Location: t.Location,
+		Slides:   t.slidesURL(generationDate),
+		Video:    t.Video,
+	}
+}
+
+// collectTalks returns the talks sorted from the most to the least recent.
+//
+// It returns an error if the slides of a talk don't exist in rootDir.
+func collectTalks(rootDir string, pages pages) ([]talk, error) {
+	var res []talk
+	for _, page := range pages.getAll(formatTalk) {
+		talk, err := parseTalk(page)
+		if err != nil {
+			return nil, fmt.Errorf("invalid talk %s, err: %w", page.sourcePath, err)
+		}
+
+		if slidesPath := talk.slidesPath(); slidesPath != "" {
+			if _, err := os.Stat(filepath.Join(rootDir, filepath.FromSlash(slidesPath))); err != nil {
+				return nil, fmt.Errorf("invalid talk %s, unable to find the slides, err: %w", page.sourcePath, err)
+			}
+		}
+
+		res = append(res, talk)
+	}
+
+	slices.SortFunc(res, func(a, b talk) int {
+		return b.page.metadata.Date.Compare(a.page.metadata.Date)
+	})
+
+	return res, nil
+}
+
+func generateTalksIndex(logger *slog.Logger, generationDate time.Time, rootDir string, buildRootDir string, pages pages) error {
+	ctx := context.Background()
+
+	assets := newAssets(generationDate)
+	assets.add("style.css")
+	assets.add("app.js")
+
+	// Generate the index page
+
+	talks, err := collectTalks(rootDir, pages)
+	if err != nil {
+		return err
+	}
+
+	items := make([]templates.Talk, 0, len(talks))
+	for _, talk := range talks {
+		items = append(items, talk.templateTalk(generationDate))
+	}
+
+	page := templates.Page(
+		templates.HeaderParams{
+			Title:       "Vincent Rischmann - Talks",
+			Description: "Talks and presentations given by Vincent Rischmann",
+		},
+		assets.underlying,
+		templates.TalksIndex(items),
+	)
+
+	// Rendering page
+
+	f, err := createOutputFile(buildRootDir, "talks.html")
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	logger.Info("generating talks index",
+		slog.String("output_path", f.Name()),
+		slog.Int("talks", len(items)),
+	)
+
+	if err := page.Render(ctx, f); err != nil {
+		return fmt.Errorf("unable to render page to file %q, err: %w", f.Name(), err)
+	}
+
+	return nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	goldmarkast "github.com/yuin/goldmark/ast"
+	goldmarkparser "github.com/yuin/goldmark/parser"
+	goldmarktext "github.com/yuin/goldmark/text"
+	goldmarkutil "github.com/yuin/goldmark/util"
+
+	"go.rischmann.fr/website-generator/templates"
+)
+
+// wikiLinkNode is an unresolved `[[page-name]]` or `[[page-name#fragment|label]]` link.
+//
+// It is replaced with a regular link by [wikiLinkTransformer] once all pages are collected.
+type wikiLinkNode struct {
+	goldmarkast.BaseInline
+
+	// Target is empty for a link to a fragment of the same page, like `[[#usage]]`
+	Target   string
+	Fragment string
+	Label    string
+}
+
+var kindWikiLink = goldmarkast.NewNodeKind("WikiLink")
+
+func (n *wikiLinkNode) Kind() goldmarkast.NodeKind { return kindWikiLink }
+
+func (n *wikiLinkNode) Dump(source []byte, level int) {
+	goldmarkast.DumpHelper(n, source, level, map[string]string{
+		"Target":   n.Target,
+		"Fragment": n.Fragment,
+		"Label":    n.Label,
+	}, nil)
+}
+
+// wikiLinkParser is a goldmarkparser.InlineParser parsing `[[page-name]]` and `[[page-name|label]]`, with an optional `#fragment`
+// after the page name.
+//
+// The label can also be separated with `\|`, which is needed in a table since the table parser splits the cells at `|`.
+type wikiLinkParser struct{}
+
+func (p wikiLinkParser) Trigger() []byte {
+	return []byte{'['}
+}
+
+func (p wikiLinkParser) Parse(parent goldmarkast.Node, block goldmarktext.Reader, pc goldmarkparser.Context) goldmarkast.Node {
+	line, _ := block.PeekLine()
+	if !bytes.HasPrefix(line, []byte("[[")) {
+		return nil
+	}
+
+	end := bytes.Index(line, []byte("]]"))
+	if end < 0 {
+		return nil
+	}
+
+	content := string(line[2:end])
+	if content == "" || strings.ContainsAny(content, "[]") {
+		return nil
+	}
+
+	content = strings.ReplaceAll(content, `\|`, "|")
+	target, label, _ := strings.Cut(content, "|")
+	target, fragment, _ := strings.Cut(target, "#")
+
+	target = strings.TrimSpace(target)
+	fragment = strings.TrimSpace(fragment)
+	if target == "" && fragment == "" {
+		return nil
+	}
+
+	block.Advance(end + 2)
+
+	return &wikiLinkNode{
+		Target:   target,
+		Fragment: fragment,
+		Label:    strings.TrimSpace(label),
+	}
+}
+
+var _ goldmarkparser.InlineParser = wikiLinkParser{}
+
+type wikiLinkExtension struct{}
+
+func (e wikiLinkExtension) Extend(m goldmark.Markdown) {
+	m.Parser().AddOptions(
+		goldmarkparser.WithInlineParsers(
+			// Must run before the standard link parser
+			goldmarkutil.Prioritized(wikiLinkParser{}, 199),
+		),
+	)
+}
+
+var _ goldmark.Extender = wikiLinkExtension{}
+
+// wikiLinkTransformer is a goldmarkast.ASTTransformer that replaces wiki links with links to the target page.
+//
+// A wiki link target is either the source path of the page without extension (`code/envconfig`) or,
+// if it's unique, the name of the markdown file without extension (`envconfig`).
+// A wiki link to an unknown or ambiguous page is an error, and so is a wiki link to a file with multiple notes
+// since it doesn't point to a single page.
+type wikiLinkTransformer struct {
+	pages     map[string]page
+	ambiguous map[string][]string
+	// multiplePages maps the wiki link targets of the files with multiple pages to their source path
+	multiplePages map[string]string
+}
+
+func newWikiLinkTransformer(pages pages) *wikiLinkTransformer {
+	res := &wikiLinkTransformer{
+		pages:         make(map[string]page),
+		ambiguous:     make(map[string][]string),
+		multiplePages: make(map[string]string),
+	}
+
+	byName := make(map[string][]page)
+	for _, page := range pages {
+		name := strings.TrimSuffix(page.sourcePath, path.Ext(page.sourcePath))
+		if _, ok := res.pages[name]; ok {
+			res.multiplePages[name] = page.sourcePath
+			continue
+		}
+		res.pages[name] = page
+
+		baseName := path.Base(name)
+		byName[baseName] = append(byName[baseName], page)
+	}
+
+	for name, candidates := range byName {
+		if _, ok := res.pages[name]; ok {
+			continue
+		}
+		if len(candidates) == 1 {
+			res.pages[name] = candidates[0]
+			if sourcePath, ok := res.multiplePages[strings.TrimSuffix(candidates[0].sourcePath, path.Ext(candidates[0].sourcePath))]; ok {
+				res.multiplePages[name] = sourcePath
+			}
+			continue
+		}
+		for _, candidate := range candidates {
+			res.ambiguous[name] = append(res.ambiguous[name], candidate.sourcePath)
+		}
+	}
+
+	return res
+}
+
+func (t *wikiLinkTransformer) Transform(node *goldmarkast.Document, reader goldmarktext.Reader, pc goldmarkparser.Context) {
+	var wikiLinks []*wikiLinkNode
+	goldmarkast.Walk(node, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+		if wikiLink, ok := n.(*wikiLinkNode); ok && entering {
+			wikiLinks = append(wikiLinks, wikiLink)
+		}
+		return goldmarkast.WalkContinue, nil
+	})
+
+	for _, wikiLink := range wikiLinks {
+		destination, label := "#"+wikiLink.Fragment, wikiLink.Fragment
+
+		// An empty target is a link to a fragment of the same page
+		if wikiLink.Target != "" {
+			target, ok := t.pages[wikiLink.Target]
+			if !ok {
+				if candidates, ok := t.ambiguous[wikiLink.Target]; ok {
+					addTransformError(pc, fmt.Errorf("wiki link to %q is ambiguous, it can be any of %s", wikiLink.Target, strings.Join(candidates, ", ")))
+				} else {
+					addTransformError(pc, fmt.Errorf("wiki link to %q points to a page which doesn't exist", wikiLink.Target))
+				}
+				continue
+			}
+			if sourcePath, ok := t.multiplePages[wikiLink.Target]; ok {
+				addTransformError(pc, fmt.Errorf("wiki link to %q points to %s which contains multiple notes, use a link to the URL of a note instead", wikiLink.Target, sourcePath))
+				continue
+			}
+
+			destination = target.url()
+			if wikiLink.Fragment != "" {
+				destination += "#" + wikiLink.Fragment
+			}
+
+			label = target.metadata.Title
+			if label == "" {
+				label = wikiLink.Target
+			}
+		}
+		if wikiLink.Label != "" {
+			label = wikiLink.Label
+		}
+
+		link := goldmarkast.NewLink()
+		link.Destination = []byte(destination)
+		link.AppendChild(link, goldmarkast.NewString([]byte(label)))
+
+		parent := wikiLink.Parent()
+		parent.ReplaceChild(parent, wikiLink, link)
+	}
+}
+
+var _ goldmarkparser.ASTTransformer = (*wikiLinkTransformer)(nil)
+
+// collectBacklinks builds the reverse link graph of the pages: for every page URL, the list of pages linking to it.
+//
+// It must be called after all links are resolved, see [transformPages].
+func collectBacklinks(pages pages) map[string][]templates.Link {
+	pagesByURL := make(map[string]page, len(pages))
+	for _, page := range pages {
+		pagesByURL[page.url()] = page
+	}
+
+	res := make(map[string][]templates.Link)
+	for _, source := range pages {
+		if !source.isRendered() {
+			continue
+		}
+
+		seen := make(map[string]struct{})
+		goldmarkast.Walk(source.markdownDocument, func(n goldmarkast.Node, entering bool) (goldmarkast.WalkStatus, error) {
+			link, ok := n.(*goldmarkast.Link)
+			if !ok || !entering {
+				return goldmarkast.WalkContinue, nil
+			}
+
+			url, _, _ := strings.Cut(string(link.Destination), "#")
+			target, ok := pagesByURL[url]
+			if !ok || target.path == source.path {
+				return goldmarkast.WalkContinue, nil
+			}
+			if _, ok := seen[url]; ok {
+				return goldmarkast.WalkContinue, nil
+			}
+			seen[url] = struct{}{}
+
+			res[url] = append(res[url], templates.Link{
+				URL:  source.url(),
+				Text: source.metadata.Title,
+			})
+
+			return goldmarkast.WalkContinue, nil
+		})
+	}
+
+	for _, links := range res {
+		slices.SortFunc(links, func(a, b templates.Link) int {
+			return strings.Compare(a.Text, b.Text)
+		})
+	}
+
+	return res
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWikiLinks(t *testing.T) {
+	files := map[string]string{
+		"code/zig-sqlite.md":             "---\nformat: standard\ntitle: zig-sqlite\n---\nZig SQLite.\n",
+		"blog/how-i-built-zig-sqlite.md": "---\nformat: blog_entry\ntitle: How I built zig-sqlite\ndate: 2024 March 02\n---\nPost.\n",
+		"code/envconfig.md":              "---\nformat: standard\n---\nEnvconfig.\n",
+		"blog/envconfig.md":              "---\nformat: blog_entry\ntitle: Envconfig\ndate: 2024 March 05\n---\nPost.\n",
+	}
+
+	testCases := []struct {
+		name    string
+		source  string
+		want    string
+		wantErr string
+	}{
+		{name: "file name", source: "[[zig-sqlite]]", want: `<a href="/code/zig-sqlite">zig-sqlite</a>`},
+		{name: "path", source: "[[code/envconfig]]", want: `<a href="/code/envconfig">code/envconfig</a>`},
+		{name: "label", source: "[[how-i-built-zig-sqlite|my post]]", want: `<a href="/blog/how-i-built-zig-sqlite">my post</a>`},
+		{name: "fragment", source: "[[zig-sqlite#usage]]", want: `<a href="/code/zig-sqlite#usage">zig-sqlite</a>`},
+		{name: "fragment and label", source: "[[zig-sqlite#usage|usage]]", want: `<a href="/code/zig-sqlite#usage">usage</a>`},
+		{name: "same page fragment", source: "[[#usage]]", want: `<a href="#usage">usage</a>`},
+		{
+			name:   "escaped pipe in a table",
+			source: "| Project | Post |\n|---|---|\n| [[zig-sqlite]] | [[how-i-built-zig-sqlite\\|my post]] |",
+			want:   `<td><a href="/blog/how-i-built-zig-sqlite">my post</a></td>`,
+		},
+		{name: "escaped pipe", source: "[[zig-sqlite\\|the library]]", want: `<a href="/code/zig-sqlite">the library</a>`},
+		{name: "ambiguous", source: "[[envconfig]]", wantErr: "is ambiguous"},
+		{name: "missing", source: "[[missing#usage]]", wantErr: "points to a page which doesn't exist"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			sources := map[string]string{"index.md": "---\nformat: standard\n---\n" + tc.source + "\n"}
+			for sourcePath, content := range files {
+				sources[sourcePath] = content
+			}
+			pages, cfg := collectTestPages(t, sources)
+
+			err := transformPages(pages, newWikiLinkTransformer(pages))
+			if tc.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+					t.Fatalf("got error %v, want an error containing %q", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if output := renderTestPage(t, cfg, pages, "index.md"); !strings.Contains(output, tc.want) {
+				t.Errorf("output doesn't contain %s, output: %s", tc.want, output)
+			}
+		})
+	}
+}